@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/packusecase"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/spf13/pflag"
+)
+
+// Input columns read by batch. Only quantity is required; all other columns are copied to the output.
+const (
+	columnQuantity = "quantity"
+	columnCatalog  = "catalog" // Pack sizes for this order, separated by | or spaces, e.g. 250|500|1000
+	columnExact    = "exact"   // true to only accept exact combinations
+	columnUnder    = "under"   // Tolerance below the quantity
+	columnOver     = "over"    // Tolerance above the quantity
+	columnUnit     = "unit"    // Tolerance unit, absolute or percent
+)
+
+// order is one input row.
+type order struct {
+	record   []string
+	quantity int
+	catalog  string
+	opts     packusecase.CalculateOptions
+	err      error // Set when the row cannot be parsed
+}
+
+// result is the outcome of one order.
+type result struct {
+	output packusecase.CalculatePacksOutput
+	err    error
+}
+
+// batchSummary totals the results of a batch.
+type batchSummary struct {
+	Orders        int         `json:"orders"`
+	Succeeded     int         `json:"succeeded"`
+	Failed        int         `json:"failed"`
+	OrderedItems  int         `json:"ordered_items"`  // Sum of the quantities of succeeded orders
+	ShippedItems  int         `json:"shipped_items"`  // Sum of the items in their packs
+	TotalPacks    int         `json:"total_packs"`    // Packs of all sizes
+	TotalOverage  int         `json:"total_overage"`  // Items shipped beyond the ordered quantities
+	TotalShortage int         `json:"total_shortage"` // Items missing where a tolerance allowed undershipping
+	PacksBySize   map[int]int `json:"packs_by_size"`
+}
+
+// runBatch implements `batch --in FILE --out FILE`, which calculates the packs for every row of a CSV file.
+func runBatch(args []string, out io.Writer) error {
+	fs := pflag.NewFlagSet("batch", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: packctl batch --in FILE --out FILE [--sizes LIST | --sizes-file FILE | --db] [flags]")
+		fs.PrintDefaults()
+	}
+	in := fs.String("in", "", "CSV file of orders with a quantity column; - reads stdin")
+	outFile := fs.String("out", "", "CSV file to write one result row per order to; - writes stdout")
+	summaryFile := fs.String("summary", "", "also write the summary report as JSON to this file")
+	delimiter := fs.String("delimiter", ",", "field delimiter of both files, e.g. ; for spreadsheets with decimal commas")
+	workers := fs.Int("workers", runtime.NumCPU(), "orders calculated in parallel")
+	var source packSource
+	source.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *in == "" || *outFile == "" {
+		return fmt.Errorf("%w: batch needs --in and --out", errUsage)
+	}
+	sep, size := utf8.DecodeRuneInString(*delimiter)
+	if size == 0 || size != len(*delimiter) {
+		return fmt.Errorf("%w: --delimiter must be a single character", errUsage)
+	}
+	if *workers < 1 {
+		return fmt.Errorf("%w: --workers must be at least 1", errUsage)
+	}
+
+	header, orders, err := readOrders(*in, sep)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	// Rows with a catalog bring their own sizes, so a default source is only needed for the others.
+	var defaultRepo domain.PackSetRepository
+	if source.given() {
+		if defaultRepo, err = source.repo(ctx); err != nil {
+			return err
+		}
+	}
+
+	results := calculateOrders(ctx, orders, defaultRepo, source.maxPackSize, *workers)
+	summary := summarize(orders, results)
+
+	if err := writeResults(*outFile, sep, header, orders, results); err != nil {
+		return err
+	}
+	if *summaryFile != "" {
+		data, _ := json.MarshalIndent(summary, "", "  ")
+		if err := os.WriteFile(*summaryFile, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+	}
+	if *outFile != "-" {
+		if err := printSummary(out, summary); err != nil {
+			return err
+		}
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d orders failed, see the error column", summary.Failed, summary.Orders)
+	}
+	return nil
+}
+
+// readOrders parses the input file. Rows that cannot be parsed, including rows with more or fewer
+// fields than the header, are kept with their error, so one bad row does not fail the whole file.
+func readOrders(name string, sep rune) ([]string, []order, error) {
+	data, err := readInput(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Spreadsheet programs often start CSV exports with a byte order mark.
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = sep
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1 // Checked per row against the header below
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("%s is empty", name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[columnQuantity]; !ok {
+		return nil, nil, fmt.Errorf("%s has no %s column", name, columnQuantity)
+	}
+
+	var orders []order
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			orders = append(orders, order{record: record, err: fmt.Errorf("line %d: %w", parseErr.Line, parseErr.Err)})
+		case err != nil:
+			return nil, nil, fmt.Errorf("failed to read %s: %w", name, err)
+		case len(record) != len(header):
+			line, _ := r.FieldPos(0)
+			orders = append(orders, order{
+				record: record,
+				err:    fmt.Errorf("line %d: has %d fields, the header has %d", line, len(record), len(header)),
+			})
+		default:
+			orders = append(orders, parseOrder(record, columns))
+		}
+	}
+	return header, orders, nil
+}
+
+// readInput reads a file, or stdin for "-".
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+	return data, nil
+}
+
+// parseOrder reads the known columns of one record.
+func parseOrder(record []string, columns map[string]int) order {
+	o := order{record: record}
+	value := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var err error
+	if o.quantity, err = strconv.Atoi(value(columnQuantity)); err != nil {
+		o.err = fmt.Errorf("quantity %q is not a number", value(columnQuantity))
+		return o
+	}
+	o.catalog = value(columnCatalog)
+
+	if exact := value(columnExact); exact != "" {
+		if o.opts.ExactOnly, err = strconv.ParseBool(exact); err != nil {
+			o.err = fmt.Errorf("exact %q is not true or false", exact)
+			return o
+		}
+	}
+	under, over := value(columnUnder), value(columnOver)
+	if under == "" && over == "" {
+		return o
+	}
+	tolerance := &packusecase.Tolerance{Unit: packusecase.ToleranceUnit(value(columnUnit))}
+	if tolerance.Unit == "" {
+		tolerance.Unit = packusecase.ToleranceAbsolute
+	}
+	if tolerance.Unit != packusecase.ToleranceAbsolute && tolerance.Unit != packusecase.TolerancePercent {
+		o.err = fmt.Errorf("unit %q is not absolute or percent", tolerance.Unit)
+		return o
+	}
+	for _, bound := range []struct {
+		text  string
+		value *float64
+	}{{under, &tolerance.Under}, {over, &tolerance.Over}} {
+		if bound.text == "" {
+			continue
+		}
+		if *bound.value, err = strconv.ParseFloat(bound.text, 64); err != nil || *bound.value < 0 {
+			o.err = fmt.Errorf("tolerance %q is not a non-negative number", bound.text)
+			return o
+		}
+	}
+	o.opts.Tolerance = tolerance
+	return o
+}
+
+// calculateOrders runs the orders through the pack use case on workers goroutines.
+// Orders with the same catalog share one use case; catalog sizes above maxPackSize fail their orders.
+func calculateOrders(
+	ctx context.Context, orders []order, defaultRepo domain.PackSetRepository, maxPackSize, workers int,
+) []result {
+	results := make([]result, len(orders))
+	useCases := map[string]*packusecase.PackUseCase{}
+	if defaultRepo != nil {
+		useCases[""] = packusecase.NewPackUseCase(defaultRepo)
+	}
+	catalogErrs := map[string]error{}
+	for _, o := range orders {
+		if o.err != nil || o.catalog == "" {
+			continue
+		}
+		if _, ok := useCases[o.catalog]; ok {
+			continue
+		}
+		repo, err := catalogRepo(o.catalog, maxPackSize)
+		if err != nil {
+			catalogErrs[o.catalog] = err
+			continue
+		}
+		useCases[o.catalog] = packusecase.NewPackUseCase(repo)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, max(len(orders), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				o := orders[i]
+				uc, ok := useCases[o.catalog]
+				switch {
+				case o.err != nil:
+					results[i] = result{err: o.err}
+				case catalogErrs[o.catalog] != nil:
+					results[i] = result{err: catalogErrs[o.catalog]}
+				case !ok:
+					results[i] = result{err: errors.New("no catalog column and no --sizes, --sizes-file or --db given")}
+				default:
+					output, err := uc.CalculatePacksWithOptions(ctx, o.quantity, o.opts)
+					results[i] = result{output: output, err: err}
+				}
+			}
+		}()
+	}
+	for i := range orders {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// catalogRepo parses a catalog cell such as "250|500|1000".
+func catalogRepo(catalog string, maxPackSize int) (domain.PackSetRepository, error) {
+	fields := strings.FieldsFunc(catalog, func(r rune) bool { return r == '|' || r == ' ' })
+	sizes := make([]int, 0, len(fields))
+	for _, field := range fields {
+		size, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("catalog %q: pack size %q is not a number", catalog, field)
+		}
+		sizes = append(sizes, size)
+	}
+	return newMemRepo(sizes, maxPackSize)
+}
+
+// writeResults writes the input columns of every order followed by its status and result columns,
+// with one pack_<size> column for every size used in the batch.
+func writeResults(name string, sep rune, header []string, orders []order, results []result) error {
+	var sizes []int
+	for _, res := range results {
+		for _, pack := range res.output.Packs {
+			sizes = append(sizes, pack.Size)
+		}
+	}
+	slices.Sort(sizes)
+	sizes = slices.Compact(sizes)
+
+	var w io.Writer = os.Stdout
+	if name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	cw.Comma = sep
+
+	_ = cw.Write(slices.Concat(header, []string{"status", "error"}, resultHeader(sizes)[1:]))
+	for i, o := range orders {
+		record := make([]string, len(header))
+		copy(record, o.record)
+		status, message, values := "ok", "", resultRow(o.quantity, results[i].output, sizes)[1:]
+		if results[i].err != nil {
+			status, message = "error", results[i].err.Error()
+			values = make([]string, len(values))
+		}
+		_ = cw.Write(slices.Concat(record, []string{status, message}, values))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return bw.Flush()
+}
+
+// summarize totals the results.
+func summarize(orders []order, results []result) batchSummary {
+	summary := batchSummary{Orders: len(orders), PacksBySize: map[int]int{}}
+	for i, res := range results {
+		if res.err != nil {
+			summary.Failed++
+			continue
+		}
+		summary.Succeeded++
+		summary.OrderedItems += orders[i].quantity
+		summary.ShippedItems += res.output.TotalItems
+		summary.TotalPacks += res.output.TotalPacks
+		if res.output.Difference > 0 {
+			summary.TotalOverage += res.output.Difference
+		} else {
+			summary.TotalShortage -= res.output.Difference
+		}
+		for _, pack := range res.output.Packs {
+			summary.PacksBySize[pack.Size] += pack.Count
+		}
+	}
+	return summary
+}
+
+// printSummary writes the summary report as a table.
+func printSummary(w io.Writer, summary batchSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Orders\t%d\n", summary.Orders)
+	fmt.Fprintf(tw, "Succeeded\t%d\n", summary.Succeeded)
+	fmt.Fprintf(tw, "Failed\t%d\n", summary.Failed)
+	fmt.Fprintf(tw, "Ordered items\t%d\n", summary.OrderedItems)
+	fmt.Fprintf(tw, "Shipped items\t%d\n", summary.ShippedItems)
+	fmt.Fprintf(tw, "Total packs\t%d\n", summary.TotalPacks)
+	fmt.Fprintf(tw, "Total overage\t%d\n", summary.TotalOverage)
+	if summary.TotalShortage > 0 {
+		fmt.Fprintf(tw, "Total shortage\t%d\n", summary.TotalShortage)
+	}
+
+	sizes := make([]int, 0, len(summary.PacksBySize))
+	for size := range summary.PacksBySize {
+		sizes = append(sizes, size)
+	}
+	slices.Sort(sizes)
+	for _, size := range sizes {
+		fmt.Fprintf(tw, "Packs of %d\t%d\n", size, summary.PacksBySize[size])
+	}
+	return tw.Flush()
+}
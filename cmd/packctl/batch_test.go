@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOrders(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedErrs   []string // Error of every row, empty for rows that parsed
+		expectedErrMsg string
+	}{
+		{
+			name:         "AllRowsValid",
+			input:        "\ufeffQuantity, Catalog,exact\n501,250|500,\n12001,,true\n",
+			expectedErrs: []string{"", ""},
+		},
+		{
+			name:  "RaggedRowsAreKept",
+			input: "quantity,catalog\n501,250\n502\n503,250,extra\n504,250\n",
+			expectedErrs: []string{
+				"",
+				"line 3: has 1 fields, the header has 2",
+				"line 4: has 3 fields, the header has 2",
+				"",
+			},
+		},
+		{
+			name:         "BadQuoteIsKept",
+			input:        "quantity,catalog\n501,250\n502,\"250\"x\n503,250\n",
+			expectedErrs: []string{"", `line 3: extraneous or missing " in quoted-field`, ""},
+		},
+		{
+			name:  "BadValues",
+			input: "quantity,exact,under,over,unit\nmany,,,,\n501,maybe,,,\n501,,-1,,\n501,,1,,boxes\n501,,1,5,percent\n",
+			expectedErrs: []string{
+				`quantity "many" is not a number`,
+				`exact "maybe" is not true or false`,
+				`tolerance "-1" is not a non-negative number`,
+				`unit "boxes" is not absolute or percent`,
+				"",
+			},
+		},
+		{
+			name:           "NoQuantityColumn",
+			input:          "amount\n501\n",
+			expectedErrMsg: "orders.csv has no quantity column",
+		},
+		{
+			name:           "Empty",
+			input:          "",
+			expectedErrMsg: "orders.csv is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			require.NoError(t, os.WriteFile("orders.csv", []byte(tt.input), 0o644))
+
+			_, orders, err := readOrders("orders.csv", ',')
+			if tt.expectedErrMsg != "" {
+				assert.EqualError(t, err, tt.expectedErrMsg)
+				return
+			}
+			require.NoError(t, err)
+			errs := make([]string, len(orders))
+			for i, o := range orders {
+				if o.err != nil {
+					errs[i] = o.err.Error()
+				}
+			}
+			assert.Equal(t, tt.expectedErrs, errs)
+		})
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		args           []string
+		expectedOutput string
+		expectedErrMsg string
+	}{
+		{
+			name:  "OutputColumns",
+			input: "id,quantity,catalog\na,501,\nb,12001,\nc,263,23|31|53\n",
+			args:  []string{"--sizes", "250,500,1000,2000,5000"},
+			expectedOutput: "id,quantity,catalog,status,error,total_items,remaining_items,difference,total_packs," +
+				"pack_23,pack_31,pack_250,pack_500,pack_2000,pack_5000\n" +
+				"a,501,,ok,,750,249,249,2,0,0,1,1,0,0\n" +
+				"b,12001,,ok,,12250,249,249,4,0,0,1,0,1,2\n" +
+				"c,263,23|31|53,ok,,263,0,0,9,2,7,0,0,0,0\n",
+		},
+		{
+			name:  "ErrorRows",
+			input: "id,quantity,catalog\na,501,\nb,,\nc\nd,501,250|0\n",
+			args:  []string{"--sizes", "250,500"},
+			expectedOutput: "id,quantity,catalog,status,error,total_items,remaining_items,difference,total_packs,pack_250,pack_500\n" +
+				"a,501,,ok,,750,249,249,2,1,1\n" +
+				"b,,,error,\"quantity \"\"\"\" is not a number\",,,,,,\n" +
+				"c,,,error,\"line 4: has 1 fields, the header has 3\",,,,,,\n" +
+				"d,501,250|0,error,invalid pack set: pack size 0 must be greater than 0,,,,,,\n",
+			expectedErrMsg: "3 of 4 orders failed, see the error column",
+		},
+		{
+			name:           "Semicolons",
+			input:          "quantity;under\n501;1\n",
+			args:           []string{"--sizes", "250,500", "--delimiter", ";"},
+			expectedOutput: "quantity;under;status;error;total_items;remaining_items;difference;total_packs;pack_500\n501;1;ok;;500;0;-1;1;1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			in, out := filepath.Join(dir, "orders.csv"), filepath.Join(dir, "results.csv")
+			require.NoError(t, os.WriteFile(in, []byte(tt.input), 0o644))
+
+			var summary bytes.Buffer
+			err := runBatch(append([]string{"--in", in, "--out", out}, tt.args...), &summary)
+			if tt.expectedErrMsg != "" {
+				assert.EqualError(t, err, tt.expectedErrMsg)
+			} else {
+				require.NoError(t, err)
+			}
+			data, err := os.ReadFile(out)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, string(data))
+		})
+	}
+}
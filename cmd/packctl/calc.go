@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"pack_optimizer/internal/usecase/packusecase"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// runCalc implements `calc QUANTITY`, which prints the optimal packs for one order.
+func runCalc(args []string, out io.Writer) error {
+	fs := pflag.NewFlagSet("calc", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: packctl calc QUANTITY (--sizes LIST | --sizes-file FILE | --db) [flags]")
+		fs.PrintDefaults()
+	}
+	var source packSource
+	source.register(fs)
+	format := fs.StringP("format", "o", formatTable, "output format: table, json or csv")
+	var opts calcOptions
+	opts.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: calc takes exactly one QUANTITY", errUsage)
+	}
+	quantity, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%w: quantity %q is not a number", errUsage, fs.Arg(0))
+	}
+	printer, err := newPrinter(*format)
+	if err != nil {
+		return err
+	}
+	calculateOptions, err := opts.build(fs)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo, err := source.repo(ctx)
+	if err != nil {
+		return err
+	}
+	output, err := packusecase.NewPackUseCase(repo).CalculatePacksWithOptions(ctx, quantity, calculateOptions)
+	if err != nil {
+		return err
+	}
+	return printer(out, quantity, output)
+}
+
+// calcOptions holds the flags that restrict acceptable combinations, as in the calculate API.
+type calcOptions struct {
+	exact bool
+	under float64
+	over  float64
+	unit  string
+}
+
+// register adds the option flags to fs.
+func (o *calcOptions) register(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.exact, "exact", false, "only accept combinations that ship exactly the ordered quantity")
+	fs.Float64Var(&o.under, "under", 0, "tolerance: how far below the quantity a plan may fall")
+	fs.Float64Var(&o.over, "over", 0, "tolerance: how far above the quantity a plan may go")
+	fs.StringVar(&o.unit, "unit", string(packusecase.ToleranceAbsolute), "tolerance unit: absolute or percent")
+}
+
+// build converts the flags into packusecase options. A tolerance is set when --under or --over is given.
+func (o *calcOptions) build(fs *pflag.FlagSet) (packusecase.CalculateOptions, error) {
+	opts := packusecase.CalculateOptions{ExactOnly: o.exact}
+	if !fs.Changed("under") && !fs.Changed("over") {
+		return opts, nil
+	}
+
+	unit := packusecase.ToleranceUnit(o.unit)
+	switch {
+	case o.exact:
+		return opts, fmt.Errorf("%w: --exact cannot be combined with --under or --over", errUsage)
+	case unit != packusecase.ToleranceAbsolute && unit != packusecase.TolerancePercent:
+		return opts, fmt.Errorf("%w: --unit must be absolute or percent", errUsage)
+	case o.under < 0 || o.over < 0:
+		return opts, fmt.Errorf("%w: --under and --over must not be negative", errUsage)
+	}
+	opts.Tolerance = &packusecase.Tolerance{Under: o.under, Over: o.over, Unit: unit}
+	return opts, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"pack_optimizer/internal/domain"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCalc(t *testing.T) {
+	sizesFile := filepath.Join(t.TempDir(), "sizes.yaml")
+	require.NoError(t, os.WriteFile(sizesFile, []byte("packs: [250, 500, 1000, 2000, 5000]\n"), 0o644))
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedErr    error
+		expectedErrMsg string
+	}{
+		{
+			name: "Table",
+			args: []string{"12001", "--sizes", "250,500,1000,2000,5000"},
+			expectedOutput: "SIZE  COUNT\n250   1\n2000  1\n5000  2\n\n" +
+				"Quantity     12001\nTotal items  12250\nTotal packs  4\nDifference   +249\n",
+		},
+		{
+			name: "CSVFromSizesFile",
+			args: []string{"12001", "--sizes-file", sizesFile, "-o", "csv"},
+			expectedOutput: "quantity,total_items,remaining_items,difference,total_packs,pack_250,pack_2000,pack_5000\n" +
+				"12001,12250,249,249,4,1,1,2\n",
+		},
+		{
+			name:           "ToleranceAllowsUndershipping",
+			args:           []string{"501", "--sizes", "250,500", "--under", "1", "-o", "csv"},
+			expectedOutput: "quantity,total_items,remaining_items,difference,total_packs,pack_500\n501,500,0,-1,1,1\n",
+		},
+		{
+			name:           "HugeToleranceDoesNotOverflow",
+			args:           []string{"501", "--sizes", "250,500", "--over", "1e20", "-o", "csv"},
+			expectedOutput: "quantity,total_items,remaining_items,difference,total_packs,pack_250,pack_500\n501,750,249,249,2,1,1\n",
+		},
+		{
+			name:           "ExactFailsWithoutExactFit",
+			args:           []string{"501", "--sizes", "250,500", "--exact"},
+			expectedErrMsg: "no pack combination adds up to exactly 501 items",
+		},
+		{
+			name:        "MissingQuantity",
+			args:        []string{"--sizes", "250"},
+			expectedErr: errUsage,
+		},
+		{
+			name:        "QuantityNotANumber",
+			args:        []string{"many", "--sizes", "250"},
+			expectedErr: errUsage,
+		},
+		{
+			name:        "UnknownFormat",
+			args:        []string{"501", "--sizes", "250", "-o", "xml"},
+			expectedErr: errUsage,
+		},
+		{
+			name:        "ExactWithTolerance",
+			args:        []string{"501", "--sizes", "250", "--exact", "--over", "5"},
+			expectedErr: errUsage,
+		},
+		{
+			name:        "UnknownUnit",
+			args:        []string{"501", "--sizes", "250", "--over", "5", "--unit", "boxes"},
+			expectedErr: errUsage,
+		},
+		{
+			name:        "NegativeTolerance",
+			args:        []string{"501", "--sizes", "250", "--under", "-5"},
+			expectedErr: errUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runCalc(tt.args, &out)
+			switch {
+			case tt.expectedErr != nil:
+				assert.ErrorIs(t, err, tt.expectedErr)
+			case tt.expectedErrMsg != "":
+				assert.EqualError(t, err, tt.expectedErrMsg)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestRunCalc_JSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runCalc([]string{"12001", "--sizes", "250,500,1000,2000,5000", "--format", "json"}, &out))
+	assert.JSONEq(t, `{
+		"total_items": 12250,
+		"remaining_items": 249,
+		"difference": 249,
+		"total_packs": 4,
+		"packs": [{"size": 250, "count": 1}, {"size": 2000, "count": 1}, {"size": 5000, "count": 2}]
+	}`, out.String())
+}
+
+func TestPackSource(t *testing.T) {
+	dir := t.TempDir()
+	sizesFile := filepath.Join(dir, "sizes.json")
+	require.NoError(t, os.WriteFile(sizesFile, []byte(`{"packs": [23, 31, 53]}`), 0o644))
+	hugeFile := filepath.Join(dir, "huge.yaml")
+	require.NoError(t, os.WriteFile(hugeFile, []byte("packs: [250, 1000000000]\n"), 0o644))
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedSizes []int
+		expectedErr   error
+	}{
+		{name: "Sizes", args: []string{"--sizes", "500,250"}, expectedSizes: []int{250, 500}},
+		{name: "SizesFile", args: []string{"--sizes-file", sizesFile}, expectedSizes: []int{23, 31, 53}},
+		{name: "NoSource", args: nil, expectedErr: errUsage},
+		{name: "TwoSources", args: []string{"--sizes", "250", "--sizes-file", sizesFile}, expectedErr: errUsage},
+		{name: "NotPositive", args: []string{"--sizes", "250,0"}, expectedErr: domain.ErrInvalidPackSet},
+		{name: "AboveDefaultMaximum", args: []string{"--sizes-file", hugeFile}, expectedErr: domain.ErrInvalidPackSet},
+		{name: "AboveGivenMaximum", args: []string{"--sizes", "250,500", "--max-pack-size", "400"}, expectedErr: domain.ErrInvalidPackSet},
+		{name: "MaximumDisabled", args: []string{"--sizes-file", hugeFile, "--max-pack-size", "0"}, expectedSizes: []int{250, 1000000000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			var source packSource
+			source.register(fs)
+			require.NoError(t, fs.Parse(tt.args))
+			assert.Equal(t, len(tt.args) > 0, source.given())
+
+			repo, err := source.repo(t.Context())
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			packs, err := repo.GetAllPacks(t.Context())
+			require.NoError(t, err)
+			sizes := make([]int, len(packs))
+			for i, pack := range packs {
+				sizes[i] = pack.Size
+			}
+			assert.ElementsMatch(t, tt.expectedSizes, sizes)
+		})
+	}
+}
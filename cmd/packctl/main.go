@@ -0,0 +1,67 @@
+// Command packctl runs the pack calculations from the command line, without the HTTP server.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"pack_optimizer/pkg/logpkg"
+
+	"github.com/spf13/pflag"
+)
+
+const usage = `usage: packctl <command> [flags]
+
+commands:
+  calc QUANTITY   calculate the packs for one order
+  batch           calculate the packs for every order in a CSV file
+  export          write the pack set of the database as a JSON or YAML document
+  import FILE     make the pack set of the database match a document`
+
+func main() {
+	// Only warnings reach stderr unless LOG_LEVEL asks for more, so results can be piped.
+	level := os.Getenv("LOG_LEVEL")
+	if level == "" {
+		level = "warn"
+	}
+	if err := logpkg.InitLogger(level, logpkg.FormatConsole); err != nil {
+		fmt.Fprintln(os.Stderr, "packctl:", err)
+		os.Exit(2)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "calc":
+		err = runCalc(args, os.Stdout)
+	case "batch":
+		err = runBatch(args, os.Stdout)
+	case "export":
+		err = runExport(args, os.Stdout)
+	case "import":
+		err = runImport(args, os.Stdout)
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return
+	default:
+		err = fmt.Errorf("%w: unknown command %q\n\n%s", errUsage, command, usage)
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, pflag.ErrHelp):
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "packctl:", err)
+		os.Exit(1)
+	}
+}
+
+// errUsage marks errors caused by invalid arguments; they exit with status 2.
+var errUsage = errors.New("invalid arguments")
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"pack_optimizer/internal/usecase/packusecase"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Output formats of calc.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// printer writes the result of one order.
+type printer func(w io.Writer, quantity int, output packusecase.CalculatePacksOutput) error
+
+// newPrinter returns the printer of format.
+func newPrinter(format string) (printer, error) {
+	switch format {
+	case formatTable:
+		return printTable, nil
+	case formatJSON:
+		return printJSON, nil
+	case formatCSV:
+		return printCSV, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q, expected table, json or csv", errUsage, format)
+}
+
+// printTable writes the packs, smallest first, followed by the totals.
+func printTable(w io.Writer, quantity int, output packusecase.CalculatePacksOutput) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tCOUNT")
+	for _, pack := range output.Packs {
+		fmt.Fprintf(tw, "%d\t%d\n", pack.Size, pack.Count)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "Quantity\t%d\n", quantity)
+	fmt.Fprintf(tw, "Total items\t%d\n", output.TotalItems)
+	fmt.Fprintf(tw, "Total packs\t%d\n", output.TotalPacks)
+	fmt.Fprintf(tw, "Difference\t%+d\n", output.Difference)
+	return tw.Flush()
+}
+
+// printJSON writes the output in the format of the calculate API.
+func printJSON(w io.Writer, _ int, output packusecase.CalculatePacksOutput) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
+
+// printCSV writes a header and one row, with a pack_<size> column per size used.
+func printCSV(w io.Writer, quantity int, output packusecase.CalculatePacksOutput) error {
+	sizes := make([]int, len(output.Packs))
+	for i, pack := range output.Packs {
+		sizes[i] = pack.Size
+	}
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(resultHeader(sizes))
+	_ = cw.Write(resultRow(quantity, output, sizes))
+	cw.Flush()
+	return cw.Error()
+}
+
+// resultHeader names the result columns followed by one breakdown column per pack size.
+func resultHeader(sizes []int) []string {
+	header := []string{"quantity", "total_items", "remaining_items", "difference", "total_packs"}
+	for _, size := range sizes {
+		header = append(header, "pack_"+strconv.Itoa(size))
+	}
+	return header
+}
+
+// resultRow returns the values for resultHeader(sizes); sizes the output does not use count 0.
+func resultRow(quantity int, output packusecase.CalculatePacksOutput, sizes []int) []string {
+	row := []string{
+		strconv.Itoa(quantity),
+		strconv.Itoa(output.TotalItems),
+		strconv.Itoa(output.RemainingItems),
+		strconv.Itoa(output.Difference),
+		strconv.Itoa(output.TotalPacks),
+	}
+	counts := make(map[int]int, len(output.Packs))
+	for _, pack := range output.Packs {
+		counts[pack.Size] = pack.Count
+	}
+	for _, size := range sizes {
+		row = append(row, strconv.Itoa(counts[size]))
+	}
+	return row
+}
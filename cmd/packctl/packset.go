@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"pack_optimizer/internal/usecase/packsetusecase"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// runExport implements `export [--format json|yaml] [--out FILE]`, which writes the pack set of the
+// configured database as a versioned document.
+func runExport(args []string, out io.Writer) error {
+	fs := pflag.NewFlagSet("export", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: packctl export [--format json|yaml] [--out FILE]")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "", "document format: json or yaml; defaults to the extension of --out, else yaml")
+	outFile := fs.String("out", "", "file to write the document to instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: export takes no arguments", errUsage)
+	}
+	if *format == "" {
+		*format = formatOf(*outFile, packsetusecase.FormatYAML)
+	}
+	if *format != packsetusecase.FormatJSON && *format != packsetusecase.FormatYAML {
+		return fmt.Errorf("%w: unknown format %q, expected json or yaml", errUsage, *format)
+	}
+
+	ctx := context.Background()
+	repo, cfg, err := dbRepo(ctx)
+	if err != nil {
+		return err
+	}
+	doc, err := packsetusecase.NewPackSetUseCase(repo).Export(ctx, cfg.Env)
+	if err != nil {
+		return err
+	}
+
+	if *outFile == "" {
+		return packsetusecase.EncodeDocument(out, doc, *format)
+	}
+	f, err := os.Create(*outFile)
+	if err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	if err := packsetusecase.EncodeDocument(f, doc, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runImport implements `import FILE [--dry-run] [--merge]`, which makes the pack set of the configured
+// database match a document and prints the changes.
+func runImport(args []string, out io.Writer) error {
+	fs := pflag.NewFlagSet("import", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: packctl import FILE [--dry-run] [--merge]")
+		fs.PrintDefaults()
+	}
+	dryRun := fs.Bool("dry-run", false, "print what the import would change without writing it")
+	merge := fs.Bool("merge", false, "keep stored sizes that are missing from the document")
+	format := fs.String("format", "", "document format: json or yaml; defaults to the file extension")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: import takes exactly one FILE", errUsage)
+	}
+	name := fs.Arg(0)
+	if *format == "" {
+		*format = formatOf(name, "")
+	}
+
+	data, err := readInput(name)
+	if err != nil {
+		return err
+	}
+	doc, err := packsetusecase.DecodeDocument(data, *format)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo, cfg, err := dbRepo(ctx)
+	if err != nil {
+		return err
+	}
+	uc := packsetusecase.NewPackSetUseCase(repo, packsetusecase.WithMaxPackSize(cfg.Solver.MaxPackSize))
+	diff, err := uc.Import(ctx, doc, packsetusecase.ImportOptions{Merge: *merge, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	return printDiff(out, diff, *dryRun)
+}
+
+// printDiff lists the sizes an import adds and removes.
+func printDiff(w io.Writer, diff packsetusecase.Diff, dryRun bool) error {
+	verb := "applied"
+	if dryRun {
+		verb = "dry run, nothing written"
+	}
+	if !diff.Changed() {
+		_, err := fmt.Fprintf(w, "no changes (%s)\n", verb)
+		return err
+	}
+	var b strings.Builder
+	for _, size := range diff.Added {
+		fmt.Fprintf(&b, "+ %d\n", size)
+	}
+	for _, size := range diff.Removed {
+		fmt.Fprintf(&b, "- %d\n", size)
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d unchanged (%s)\n", len(diff.Added), len(diff.Removed), len(diff.Unchanged), verb)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatOf returns the document format of a file name by its extension, or fallback.
+func formatOf(name, fallback string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return packsetusecase.FormatJSON
+	case ".yaml", ".yml":
+		return packsetusecase.FormatYAML
+	}
+	return fallback
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/repository/memrepo"
+	"pack_optimizer/internal/repository/sqlrepo"
+
+	"github.com/spf13/pflag"
+)
+
+// packSource selects where the pack sizes come from: a flag, a seed file or the configured database.
+type packSource struct {
+	sizes       []int
+	file        string
+	fromDB      bool
+	maxPackSize int // Largest size accepted from --sizes, --sizes-file or a batch catalog
+}
+
+// register adds the pack source flags to fs.
+func (s *packSource) register(fs *pflag.FlagSet) {
+	fs.IntSliceVar(&s.sizes, "sizes", nil, "comma-separated pack sizes, e.g. 250,500,1000")
+	fs.StringVar(&s.file, "sizes-file", "", "YAML or JSON file with a packs list, in the format of the seed files")
+	fs.BoolVar(&s.fromDB, "db", false, "read the pack sizes from the database configured like the server (env, CONFIG_FILE)")
+	fs.IntVar(&s.maxPackSize, "max-pack-size", configs.DefaultMaxPackSize, "largest pack size accepted from --sizes or --sizes-file, as solver.max_pack_size; 0 means no limit")
+}
+
+// given reports whether any pack source flag is set.
+func (s *packSource) given() bool {
+	return len(s.sizes) > 0 || s.file != "" || s.fromDB
+}
+
+// repo returns a repository holding the selected pack sizes.
+func (s *packSource) repo(ctx context.Context) (domain.PackSetRepository, error) {
+	selected := 0
+	for _, set := range []bool{len(s.sizes) > 0, s.file != "", s.fromDB} {
+		if set {
+			selected++
+		}
+	}
+	if selected != 1 {
+		return nil, fmt.Errorf("%w: give exactly one of --sizes, --sizes-file or --db", errUsage)
+	}
+
+	switch {
+	case len(s.sizes) > 0:
+		return newMemRepo(s.sizes, s.maxPackSize)
+	case s.file != "":
+		seed, err := db.ReadSeedFile(s.file)
+		if err != nil {
+			return nil, err
+		}
+		return newMemRepo(seed.Packs, s.maxPackSize)
+	}
+
+	repo, _, err := dbRepo(ctx)
+	return repo, err
+}
+
+// dbRepo connects to the database configured like the server (env, CONFIG_FILE) and returns its
+// pack repository together with the configuration.
+func dbRepo(ctx context.Context) (domain.PackSetRepository, configs.Config, error) {
+	cfg, err := configs.LoadConfig(nil)
+	if err != nil {
+		return nil, configs.Config{}, err
+	}
+	gormDB, err := db.Connect(ctx, cfg.DB)
+	if err != nil {
+		return nil, configs.Config{}, err
+	}
+	return sqlrepo.NewPackRepo(gormDB), cfg, nil
+}
+
+// newMemRepo returns an in-memory repository holding sizes, which must all be positive and at most maxPackSize.
+func newMemRepo(sizes []int, maxPackSize int) (domain.PackSetRepository, error) {
+	for _, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("%w: pack size %d must be greater than 0", domain.ErrInvalidPackSet, size)
+		}
+		if maxPackSize > 0 && size > maxPackSize {
+			return nil, fmt.Errorf("%w: pack size %d must not exceed %d", domain.ErrInvalidPackSet, size, maxPackSize)
+		}
+	}
+	return memrepo.NewPackRepo(sizes), nil
+}
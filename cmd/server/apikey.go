@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+)
+
+// runAPIKey implements `apikey issue --name NAME --role ROLE` and `apikey revoke --id ID`.
+// The configuration is read from the config file and environment only.
+func runAPIKey(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: apikey issue|revoke [flags]")
+	}
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		return err
+	}
+
+	gormDB, err := openDatabase(cfg.DB)
+	if err != nil {
+		return err
+	}
+	if err := migrateOnStart(cfg.DB, gormDB); err != nil {
+		return err
+	}
+	uc := apikeyusecase.NewAPIKeyUseCase(sqlrepo.NewAPIKeyRepo(gormDB))
+	ctx := context.Background()
+
+	switch args[0] {
+	case "issue":
+		fs := flag.NewFlagSet("apikey issue", flag.ContinueOnError)
+		name := fs.String("name", "", "label of the client the key is issued to")
+		role := fs.String("role", string(domain.RoleCalculator), "role granted to the key: calculator, pack-admin or auditor")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		issued, err := uc.Issue(ctx, *name, domain.Role(*role))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "id:     %d\nname:   %s\nrole:   %s\nkey:    %s\n", issued.ID, issued.Name, issued.Role, issued.Key)
+		fmt.Fprintln(os.Stderr, "Store the key now; it cannot be shown again.")
+		return nil
+	case "revoke":
+		fs := flag.NewFlagSet("apikey revoke", flag.ContinueOnError)
+		id := fs.Uint("id", 0, "ID of the key to revoke")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *id == 0 {
+			return errors.New("--id is required")
+		}
+
+		if err := uc.Revoke(ctx, *id); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "revoked api key %d\n", *id)
+		return nil
+	default:
+		return fmt.Errorf("unknown apikey command %q, expected issue or revoke", args[0])
+	}
+}
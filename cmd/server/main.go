@@ -1,36 +1,174 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
 	"pack_optimizer/configs"
 	"pack_optimizer/db"
+	"pack_optimizer/internal/handler/middlewares"
 	"pack_optimizer/internal/http"
+	"pack_optimizer/internal/usecase/tokenusecase"
+	"pack_optimizer/pkg/jwks"
 	"pack_optimizer/pkg/logpkg"
+	"pack_optimizer/pkg/tracing"
+	"strings"
+	"syscall"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/rs/zerolog/log"
 
 	_ "github.com/joho/godotenv/autoload"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
-	logpkg.InitLogger()
+	// The first argument selects the command; everything else is passed on to it.
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "config":
+		err = runConfig(args)
+	case "apikey":
+		err = runAPIKey(args)
+	case "migrate":
+		err = runMigrate(args)
+	case "seed":
+		err = runSeed(args)
+	default:
+		err = fmt.Errorf("unknown command %q, expected serve, config, apikey, migrate or seed", command)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Str("command", command).Msg("Command failed")
+	}
+}
 
+// loadConfig loads the configuration from args and sets up the global logger.
+func loadConfig(args []string) (configs.Config, error) {
 	// Load environment variables and configurations
-	cfg := configs.LoadConfig()
+	cfg, err := configs.LoadConfig(args)
+	if err != nil {
+		return configs.Config{}, err
+	}
+	if err := logpkg.InitLogger(cfg.Log.Level, cfg.Log.Format); err != nil {
+		return configs.Config{}, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	return cfg, nil
+}
+
+// runServe implements `serve [config flags]`, the default command.
+func runServe(args []string) error {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+	return serve(cfg)
+}
+
+// runConfig implements `config print [config flags]`, which shows the effective configuration.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: config print [flags]")
+	}
+	cfg, err := configs.LoadConfig(args[1:])
+	if err != nil {
+		return err
+	}
+	return configs.Print(os.Stdout, cfg)
+}
+
+// serve runs migrations and the HTTP server until a shutdown signal is received.
+func serve(cfg configs.Config) error {
+	// Set up tracing
+	shutdownTracer, err := tracing.InitTracer(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.ServiceName)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	// Flush spans that are still buffered, also when startup fails.
+	defer func() {
+		if err := shutdownTracer(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to flush traces")
+		}
+	}()
+
+	// Load the SSO key set before connecting, so a bad key set does not leave a connection behind.
+	tokens, err := newTokenAuthenticator(cfg.Auth.JWT)
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS: %w", err)
+	}
 
-	// Connect to DB
-	gormDB, err := gorm.Open(postgres.Open(cfg.DB.GormDSN), &gorm.Config{})
+	// Connect to DB, giving up cleanly when it stays unreachable or the process is interrupted.
+	gormDB, err := openDatabase(cfg.DB)
 	if err != nil {
-		log.Info().Msg("Starting server on :3000")
+		return err
 	}
 
-	// Run migrations
-	db.RunMigrations(cfg.DB.MigrateDSN)
+	// Run migrations. Once the server runs it closes the database on shutdown; until then it is closed here.
+	if err := prepareDatabase(cfg, gormDB); err != nil {
+		if sqlDB, dbErr := gormDB.DB(); dbErr == nil {
+			_ = sqlDB.Close()
+		}
+		return err
+	}
 
 	// --- Server Setup and Execution ---
-	server := http.NewServer(gormDB)
+	server := http.NewServer(gormDB, cfg, tokens)
 
 	// Run the server and handle graceful shutdown.
 	server.Run(cfg.App)
+	return nil
+}
+
+// prepareDatabase applies the migrations and seed the configuration asks for at startup.
+func prepareDatabase(cfg configs.Config, gormDB *gorm.DB) error {
+	if err := migrateOnStart(cfg.DB, gormDB); err != nil {
+		return err
+	}
+	return seedOnStart(cfg, gormDB)
+}
+
+// openDatabase connects to the configured database and exports its pool stats on /metrics.
+func openDatabase(dbConfig configs.DB) (*gorm.DB, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	gormDB, err := db.Connect(ctx, dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	prometheus.MustRegister(collectors.NewDBStatsCollector(sqlDB, dbConfig.Name))
+	return gormDB, nil
+}
+
+// newTokenAuthenticator loads the configured key set, or returns nil when bearer tokens are disabled.
+func newTokenAuthenticator(jwtConfig configs.JWT) (middlewares.Authenticator, error) {
+	if jwtConfig.JWKS == "" {
+		return nil, nil
+	}
+
+	keySet, err := jwks.Load(context.Background(), jwtConfig.JWKS, jwtConfig.JWKSRefresh)
+	if err != nil {
+		return nil, err
+	}
+	return tokenusecase.NewTokenUseCase(keySet, tokenusecase.Options{
+		Issuer:        jwtConfig.Issuer,
+		Audience:      jwtConfig.Audience,
+		RolesClaim:    jwtConfig.RolesClaim,
+		TenantClaim:   jwtConfig.TenantClaim,
+		Tenants:       jwtConfig.Tenants,
+		RequiredScope: jwtConfig.Scope,
+	}), nil
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const migrateUsage = "usage: migrate up | down [N] | goto VERSION | force VERSION | status [config flags]"
+
+// runMigrate implements `migrate up|down|goto|force|status`. down rolls back one migration unless N
+// is given; force only records VERSION and clears the dirty flag after a failed migration was fixed
+// by hand. Everything after the action and its argument is read as config flags.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action, args := args[0], args[1:]
+	switch action {
+	case "up", "down", "goto", "force", "status":
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	// down, goto and force take one positional argument before the config flags; force accepts -1.
+	var arg string
+	if len(args) > 0 && (action == "force" || !strings.HasPrefix(args[0], "-")) {
+		arg, args = args[0], args[1:]
+	}
+
+	cfg, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+	gormDB, err := openDatabase(cfg.DB)
+	if err != nil {
+		return err
+	}
+	migrator, err := db.NewMigrator(cfg.DB, gormDB)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch action {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if arg != "" {
+			if steps, err = strconv.Atoi(arg); err != nil {
+				return fmt.Errorf("invalid number of steps %q", arg)
+			}
+		}
+		err = migrator.Down(steps)
+	case "goto":
+		var version uint64
+		if version, err = strconv.ParseUint(arg, 10, 0); err != nil {
+			return fmt.Errorf("invalid version %q: %s", arg, migrateUsage)
+		}
+		err = migrator.Goto(uint(version))
+	case "force":
+		var version int
+		if version, err = strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("invalid version %q: %s", arg, migrateUsage)
+		}
+		err = migrator.Force(version)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s failed: %w", action, err)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	state := "clean"
+	if status.Dirty {
+		state = "dirty"
+	}
+	_, err = fmt.Fprintf(os.Stdout, "version %d of %d (%s, %s)\n", status.Current, status.Latest, cfg.DB.Driver, state)
+	return err
+}
+
+// migrateOnStart applies pending migrations unless auto-migration is disabled in cfg.
+func migrateOnStart(cfg configs.DB, gormDB *gorm.DB) error {
+	if !cfg.AutoMigrate {
+		log.Info().Msg("Auto-migration disabled, run `migrate up` to apply pending migrations")
+		return nil
+	}
+	return db.RunMigrations(cfg, gormDB)
+}
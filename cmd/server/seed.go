@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/packsetusecase"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// runSeed implements `seed [--file FILE] [--prune] [--dry-run]`. Without --file it applies the
+// embedded seed of the configured environment. The configuration is read from the config file and
+// environment only.
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	file := fs.String("file", "", "YAML or JSON seed file to apply instead of the environment's seed")
+	prune := fs.Bool("prune", false, "remove pack sizes that are not in the seed")
+	dryRun := fs.Bool("dry-run", false, "print the changes without writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		return err
+	}
+	seed, err := loadSeed(cfg.Env, *file)
+	if err != nil {
+		return err
+	}
+
+	gormDB, err := openDatabase(cfg.DB)
+	if err != nil {
+		return err
+	}
+	if err := migrateOnStart(cfg.DB, gormDB); err != nil {
+		return err
+	}
+
+	uc := packsetusecase.NewPackSetUseCase(sqlrepo.NewPackRepo(gormDB), packsetusecase.WithMaxPackSize(cfg.Solver.MaxPackSize))
+	diff, err := uc.Apply(context.Background(), seed.Packs, packsetusecase.ApplyOptions{Prune: *prune, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "packs added: %v\npacks removed: %v\npacks unchanged: %v\n",
+		diff.Added, diff.Removed, diff.Unchanged)
+	return err
+}
+
+// loadSeed reads file, or the embedded seed of env when file is empty.
+func loadSeed(env, file string) (db.Seed, error) {
+	if file != "" {
+		return db.ReadSeedFile(file)
+	}
+	return db.LoadSeed(env)
+}
+
+// seedOnStart applies the seed of the environment when auto-seeding is enabled in cfg.
+// Sizes are only added, so changes made since the last start are kept. An environment without a
+// seed file has nothing to seed, so the start continues.
+func seedOnStart(cfg configs.Config, gormDB *gorm.DB) error {
+	if !cfg.DB.AutoSeed {
+		return nil
+	}
+	seed, err := db.LoadSeed(cfg.Env)
+	if errors.Is(err, db.ErrNoSeed) {
+		log.Info().Str("env", cfg.Env).Msg("No seed file for the environment, nothing to seed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	uc := packsetusecase.NewPackSetUseCase(sqlrepo.NewPackRepo(gormDB), packsetusecase.WithMaxPackSize(cfg.Solver.MaxPackSize))
+	diff, err := uc.Apply(context.Background(), seed.Packs, packsetusecase.ApplyOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to seed the database: %w", err)
+	}
+	log.Info().Ints("added", diff.Added).Str("env", cfg.Env).Msg("Database seeded")
+	return nil
+}
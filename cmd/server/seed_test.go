@@ -0,0 +1,52 @@
+package main
+
+import (
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedOnStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           string
+		autoSeed      bool
+		expectedSizes []int
+	}{
+		{name: "SeedsTheEnvironment", env: "test", autoSeed: true, expectedSizes: []int{250, 500, 1000, 2000, 5000}},
+		{name: "NoSeedFile", env: "production", autoSeed: true},
+		{name: "Disabled", env: "test", autoSeed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := configs.Config{
+				Env:    tt.env,
+				DB:     configs.DB{Driver: db.DriverSQLite, Name: ":memory:", AutoSeed: tt.autoSeed},
+				Solver: configs.Solver{MaxPackSize: configs.DefaultMaxPackSize},
+			}
+			gormDB, err := db.Connect(t.Context(), cfg.DB)
+			require.NoError(t, err)
+			require.NoError(t, db.RunMigrations(cfg.DB, gormDB))
+
+			require.NoError(t, seedOnStart(cfg, gormDB))
+
+			packs, err := sqlrepo.NewPackRepo(gormDB).GetAllPacks(t.Context())
+			if tt.expectedSizes == nil {
+				assert.ErrorIs(t, err, domain.ErrNoPacksAvailable)
+				return
+			}
+			require.NoError(t, err)
+			sizes := make([]int, len(packs))
+			for i, pack := range packs {
+				sizes[i] = pack.Size
+			}
+			assert.ElementsMatch(t, tt.expectedSizes, sizes)
+		})
+	}
+}
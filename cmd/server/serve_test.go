@@ -0,0 +1,39 @@
+package main
+
+import (
+	"pack_optimizer/configs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe_StartupErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           configs.Config
+		expectedError string
+	}{
+		{
+			name:          "UnknownTracingExporter",
+			cfg:           configs.Config{Tracing: configs.Tracing{Exporter: "carrier-pigeon"}},
+			expectedError: `failed to initialize tracing: unknown tracing exporter "carrier-pigeon"`,
+		},
+		{
+			name: "MissingKeySet",
+			cfg: configs.Config{Auth: configs.Auth{JWT: configs.JWT{
+				JWKS: filepath.Join(t.TempDir(), "missing.json"),
+			}}},
+			expectedError: "failed to load JWKS: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := serve(tt.cfg)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
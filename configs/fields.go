@@ -0,0 +1,42 @@
+package configs
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// field is one setting of Config, addressed by its dotted key.
+type field struct {
+	key    string
+	path   []int // Field index path within Config, for reflect.Value.FieldByIndex
+	secret bool
+}
+
+// fields lists every setting of Config in declaration order.
+func fields() []field {
+	return collectFields(reflect.TypeOf(Config{}), "", nil)
+}
+
+func collectFields(t reflect.Type, prefix string, index []int) []field {
+	var out []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		path := append(append([]int(nil), index...), i)
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+			childPrefix := prefix + name + "."
+			if opts == "squash" {
+				childPrefix = prefix
+			}
+			out = append(out, collectFields(sf.Type, childPrefix, path)...)
+			continue
+		}
+		out = append(out, field{key: prefix + name, path: path, secret: sf.Tag.Get("secret") == "true"})
+	}
+	return out
+}
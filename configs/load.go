@@ -3,52 +3,150 @@ package configs
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-
 	"github.com/go-playground/validator/v10"
-
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-// LoadConfig loads config from .env file and environment variables
-func LoadConfig() Config {
+// DefaultMaxPackSize is the largest pack size accepted unless solver.max_pack_size says otherwise.
+// Tools that run without a configuration use it as well.
+const DefaultMaxPackSize = 1000000
+
+// DefaultMaxStates is the state budget of one solve unless solver.max_states says otherwise. The
+// default pack set (250 to 5000) explores about 2,000,000 states at solver.max_quantity, while pack
+// sets of small, coprime sizes explore several times more and are stopped instead.
+const DefaultMaxStates = 2500000
+
+// ConfigFileEnv names the environment variable that may point to a config file instead of --config.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// defaults holds the value of every optional setting. Settings without a default must be provided.
+var defaults = map[string]any{
+	"app.shutdown_drain":         "0s",
+	"app.read_timeout":           "10s",
+	"app.write_timeout":          "60s",
+	"app.idle_timeout":           "120s",
+	"db.driver":                  "postgres",
+	"db.path":                    "pack_optimizer.db",
+	"db.auto_migrate":            true,
+	"db.auto_seed":               false,
+	"db.max_open_conns":          25,
+	"db.max_idle_conns":          5,
+	"db.conn_max_lifetime":       "30m",
+	"db.conn_max_idle_time":      "5m",
+	"db.statement_timeout":       "30s",
+	"db.connect_attempts":        10,
+	"db.connect_backoff":         "500ms",
+	"db.connect_max_backoff":     "10s",
+	"log.level":                  "info",
+	"log.format":                 "json",
+	"tracing.exporter":           "none",
+	"tracing.service_name":       "pack_optimizer",
+	"auth.anonymous_calculation": true,
+	"auth.jwks_refresh":          "1h",
+	"auth.jwt_roles_claim":       "roles",
+	"auth.jwt_tenant_claim":      "tenant",
+	"auth.jwt_tenants":           []string{},
+	"rate_limit.enabled":         true,
+	"rate_limit.store":           "memory",
+	"rate_limit.rps":             10,
+	"rate_limit.burst":           20,
+	"rate_limit.quota_rate":      1000000,
+	"rate_limit.quota_burst":     10000000,
+	"idempotency.store":          "memory",
+	"idempotency.ttl":            "24h",
+	"admission.max_concurrent":   0,
+	"admission.queue_depth":      64,
+	"admission.max_wait":         "2s",
+	"solver.max_quantity":        99999999,
+	"solver.max_states":          DefaultMaxStates,
+	"solver.max_table":           4000000,
+	"solver.max_pack_size":       DefaultMaxPackSize,
+	"history.enabled":            true,
+	"history.retention":          "2160h",
+}
+
+// LoadConfig builds the configuration from, in increasing order of precedence: the defaults above,
+// an optional YAML or TOML file, environment variables and command-line flags. The file is named by
+// --config or CONFIG_FILE. args holds the command-line flags, e.g. --app.port=9090; a .env file is
+// not required, since main loads it into the environment only if it exists.
+func LoadConfig(args []string) (Config, error) {
 	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
 
-	// --- Load .env file ---
-	v.SetConfigFile(".env")
-	v.SetConfigType("env")
-	// --- Environment variables override ---
-	v.AutomaticEnv()
+	flags := pflag.NewFlagSet("config", pflag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(ConfigFileEnv), "path of a YAML or TOML config file")
+	for _, f := range fields() {
+		flags.String(f.key, "", fmt.Sprintf("overrides %s (env %s)", f.key, envName(f.key)))
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
 
-	if err := v.ReadInConfig(); err != nil {
-		log.Fatal().Err(err).Msg("Error reading .env file")
+	for _, f := range fields() {
+		if err := v.BindEnv(f.key, envName(f.key)); err != nil {
+			return Config{}, fmt.Errorf("failed to bind %s: %w", f.key, err)
+		}
+		if err := v.BindPFlag(f.key, flags.Lookup(f.key)); err != nil {
+			return Config{}, fmt.Errorf("failed to bind flag --%s: %w", f.key, err)
+		}
 	}
 
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", ""))
+	if *configFile != "" {
+		v.SetConfigFile(*configFile)
+		if err := v.ReadInConfig(); err != nil {
+			return Config{}, fmt.Errorf("failed to read config file %s: %w", *configFile, err)
+		}
+	}
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
-		log.Fatal().Err(err).Msg("Error unmarshalling configuration")
+		return Config{}, fmt.Errorf("failed to decode configuration: %w", err)
 	}
 
 	// --- Validation for required fields using a validation library ---
-	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
-		log.Fatal().Err(err).Msg("Configuration validation failed")
+	if err := validator.New().Struct(cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Generate DSNs from loaded values
-	cfg.DB.GormDSN = fmt.Sprintf(
+	cfg.DB.GormDSN, cfg.DB.MigrateDSN = dsns(cfg.DB)
+
+	return cfg, nil
+}
+
+// dsns generates the GORM and migration DSNs of the configured driver. SQLite migrations share the
+// GORM connection, so they have no DSN of their own.
+func dsns(db DB) (gormDSN, migrateDSN string) {
+	if db.Driver == "sqlite" {
+		if db.Path == ":memory:" {
+			return "file::memory:?cache=shared&_pragma=foreign_keys(1)", ""
+		}
+		return db.Path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", ""
+	}
+
+	gormDSN = fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DB.Host, cfg.DB.Port, cfg.DB.User, cfg.DB.Password, cfg.DB.Name, cfg.DB.SSLMode,
+		db.Host, db.Port, db.User, db.Password, db.Name, db.SSLMode,
 	)
+	if db.StatementTimeout > 0 {
+		// Migrations use their own DSN, so long schema changes are not cut off.
+		gormDSN += fmt.Sprintf(" statement_timeout=%d", db.StatementTimeout.Milliseconds())
+	}
 
-	cfg.DB.MigrateDSN = fmt.Sprintf(
+	migrateDSN = fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name, cfg.DB.SSLMode,
+		db.User, db.Password, db.Host, db.Port, db.Name, db.SSLMode,
 	)
 
-	return cfg
+	return gormDSN, migrateDSN
+}
+
+// envName returns the environment variable of a key, e.g. APP_PORT for app.port.
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
 }
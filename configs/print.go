@@ -0,0 +1,55 @@
+package configs
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of secret settings in Print.
+const redacted = "<redacted>"
+
+// Print writes cfg as a YAML document that can be used as a config file. Secret settings are
+// replaced by a placeholder when they are set.
+func Print(w io.Writer, cfg Config) error {
+	root := map[string]any{}
+	value := reflect.ValueOf(cfg)
+	for _, f := range fields() {
+		var out any
+		switch v := value.FieldByIndex(f.path).Interface().(type) {
+		case time.Duration:
+			out = v.String()
+		case string:
+			out = v
+			if f.secret && v != "" {
+				out = redacted
+			}
+		default:
+			out = v
+		}
+
+		// Nest dotted keys, e.g. app.port becomes app: {port: ...}.
+		node := root
+		parts := strings.Split(f.key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = out
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("failed to print configuration: %w", err)
+	}
+	return enc.Close()
+}
@@ -1,22 +1,111 @@
 package configs
 
+import "time"
+
+// Config is the effective application configuration. Every field is addressed by the dotted path of
+// its mapstructure tags, e.g. app.port: that path is the key in a config file and the name of the
+// command-line flag (--app.port), and the environment variable is the same path in upper case with
+// dots replaced by underscores (APP_PORT). Fields tagged secret:"true" are redacted by Print.
 type Config struct {
-	App App    `mapstructure:",squash"` // Squash allows the fields of App to be directly accessible in Config
-	DB  DB     `mapstructure:",squash"`
-	Env string `mapstructure:"ENV" validate:"required"`
+	Env         string      `mapstructure:"env" validate:"required"`
+	App         App         `mapstructure:"app"`
+	DB          DB          `mapstructure:"db"`
+	Log         Log         `mapstructure:"log"`
+	Tracing     Tracing     `mapstructure:"tracing"`
+	Auth        Auth        `mapstructure:"auth"`
+	RateLimit   RateLimit   `mapstructure:"rate_limit"`
+	Idempotency Idempotency `mapstructure:"idempotency"`
+	Admission   Admission   `mapstructure:"admission"`
+	Solver      Solver      `mapstructure:"solver"`
+	History     History     `mapstructure:"history"`
 }
 
 type App struct {
-	Port string `mapstructure:"APP_PORT" validate:"required"`
+	Port          string        `mapstructure:"port" validate:"required"`
+	ShutdownDrain time.Duration `mapstructure:"shutdown_drain"`                 // How long /readyz fails before the server stops
+	ReadTimeout   time.Duration `mapstructure:"read_timeout" validate:"min=0"`  // Maximum time to read a request; 0 means no limit
+	WriteTimeout  time.Duration `mapstructure:"write_timeout" validate:"min=0"` // Maximum time to write a response, including sweep streams
+	IdleTimeout   time.Duration `mapstructure:"idle_timeout" validate:"min=0"`  // How long keep-alive connections stay open
 }
 
 type DB struct {
-	Host       string `mapstructure:"DB_HOST" validate:"required"`
-	Port       string `mapstructure:"DB_PORT" validate:"required"`
-	User       string `mapstructure:"DB_USER" validate:"required"`
-	Password   string `mapstructure:"DB_PASSWORD" validate:"required"`
-	Name       string `mapstructure:"DB_NAME" validate:"required"`
-	SSLMode    string `mapstructure:"DB_SSLMODE" validate:"required"`
+	Driver     string `mapstructure:"driver" validate:"oneof=postgres sqlite"`
+	Path       string `mapstructure:"path" validate:"required_if=Driver sqlite"` // SQLite database file, or :memory: for a database that lives in the process
+	Host       string `mapstructure:"host" validate:"required_if=Driver postgres"`
+	Port       string `mapstructure:"port" validate:"required_if=Driver postgres"`
+	User       string `mapstructure:"user" validate:"required_if=Driver postgres"`
+	Password   string `mapstructure:"password" validate:"required_if=Driver postgres" secret:"true"`
+	Name       string `mapstructure:"name" validate:"required_if=Driver postgres"`
+	SSLMode    string `mapstructure:"sslmode" validate:"required_if=Driver postgres"`
 	GormDSN    string `mapstructure:"-"`
 	MigrateDSN string `mapstructure:"-"`
+
+	MaxOpenConns      int           `mapstructure:"max_open_conns" validate:"min=0"`     // Connections open at once; 0 means no limit
+	MaxIdleConns      int           `mapstructure:"max_idle_conns" validate:"min=0"`     // Idle connections kept in the pool
+	ConnMaxLifetime   time.Duration `mapstructure:"conn_max_lifetime" validate:"min=0"`  // Connections are closed after this age; 0 keeps them
+	ConnMaxIdleTime   time.Duration `mapstructure:"conn_max_idle_time" validate:"min=0"` // Idle connections are closed after this long
+	StatementTimeout  time.Duration `mapstructure:"statement_timeout" validate:"min=0"`  // Server-side limit per statement on Postgres; 0 means no limit
+	ConnectAttempts   int           `mapstructure:"connect_attempts" validate:"min=1"`   // Connection attempts at startup before giving up
+	ConnectBackoff    time.Duration `mapstructure:"connect_backoff" validate:"gt=0"`     // Wait after the first failed attempt, doubled each time
+	ConnectMaxBackoff time.Duration `mapstructure:"connect_max_backoff" validate:"gt=0"` // Longest wait between attempts
+	AutoMigrate       bool          `mapstructure:"auto_migrate"`                        // Apply pending migrations at startup; otherwise run `migrate up` first
+	AutoSeed          bool          `mapstructure:"auto_seed"`                           // Add the pack sizes of the environment's seed file at startup
+}
+
+type Log struct {
+	Level  string `mapstructure:"level" validate:"oneof=trace debug info warn error fatal panic disabled"`
+	Format string `mapstructure:"format" validate:"oneof=json console"`
+}
+
+type Tracing struct {
+	Exporter    string `mapstructure:"exporter" validate:"oneof=otlp stdout none"` // Where spans are sent
+	ServiceName string `mapstructure:"service_name" validate:"required"`           // service.name resource attribute
+}
+
+type Auth struct {
+	AnonymousCalculation bool `mapstructure:"anonymous_calculation"` // Allow calculation endpoints without an API key
+	JWT                  JWT  `mapstructure:",squash"`
+}
+
+type JWT struct {
+	JWKS        string        `mapstructure:"jwks"`                                              // File path or http(s) URL of the issuer's key set; bearer tokens are rejected when empty
+	JWKSRefresh time.Duration `mapstructure:"jwks_refresh"`                                      // How often a key set loaded from a URL is refetched
+	Issuer      string        `mapstructure:"jwt_issuer"`                                        // Expected iss claim
+	Audience    string        `mapstructure:"jwt_audience"`                                      // Expected aud claim
+	Scope       string        `mapstructure:"jwt_scope"`                                         // Scope every token must grant
+	RolesClaim  string        `mapstructure:"jwt_roles_claim"`                                   // Claim mapped to roles, e.g. realm_access.roles
+	TenantClaim string        `mapstructure:"jwt_tenant_claim" validate:"required_with=Tenants"` // Claim mapped to the tenant
+	Tenants     []string      `mapstructure:"jwt_tenants"`                                       // Tenants whose tokens are accepted, comma separated in env; any tenant when empty
+}
+
+type RateLimit struct {
+	Enabled    bool    `mapstructure:"enabled"`
+	Store      string  `mapstructure:"store" validate:"oneof=memory database"` // database shares buckets between instances
+	Rate       float64 `mapstructure:"rps" validate:"gt=0"`                    // Requests per second per client
+	Burst      float64 `mapstructure:"burst" validate:"gt=0"`                  // Requests a client may send at once
+	QuotaRate  float64 `mapstructure:"quota_rate" validate:"gt=0"`             // Ordered items per second per client
+	QuotaBurst float64 `mapstructure:"quota_burst" validate:"gt=0"`            // Ordered items a client may request at once
+}
+
+type Idempotency struct {
+	Store string        `mapstructure:"store" validate:"oneof=memory database"` // database recognises retries on every instance
+	TTL   time.Duration `mapstructure:"ttl" validate:"gt=0"`                    // How long responses are kept for replay
+}
+
+type Admission struct {
+	MaxConcurrent int           `mapstructure:"max_concurrent" validate:"min=0"` // Solves running at once; 0 uses the number of CPUs
+	QueueDepth    int           `mapstructure:"queue_depth" validate:"min=0"`    // Solves waiting for a slot before new ones are rejected
+	MaxWait       time.Duration `mapstructure:"max_wait" validate:"gt=0"`        // How long a solve may wait for a slot
+}
+
+type Solver struct {
+	MaxQuantity int `mapstructure:"max_quantity" validate:"gt=0"`  // Largest order quantity accepted
+	MaxStates   int `mapstructure:"max_states" validate:"min=0"`   // States one solve may explore before giving up; 0 means no limit
+	MaxTable    int `mapstructure:"max_table" validate:"min=0"`    // Totals a sweep may tabulate (8 bytes each) before it solves quantities one by one; 0 means no limit
+	MaxPackSize int `mapstructure:"max_pack_size" validate:"gt=0"` // Largest pack size that may be stored
+}
+
+type History struct {
+	Enabled   bool          `mapstructure:"enabled"`                    // Record every calculation so it can be reviewed and shared at /history
+	Retention time.Duration `mapstructure:"retention" validate:"min=0"` // Calculations older than this are deleted; 0 keeps them forever
 }
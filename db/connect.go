@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/configs"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/rs/zerolog/log"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Supported values of configs.DB.Driver.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialector returns the GORM dialector for the configured driver.
+func Dialector(cfg configs.DB) gorm.Dialector {
+	if cfg.Driver == DriverSQLite {
+		return sqlite.Open(cfg.GormDSN)
+	}
+	return postgres.Open(cfg.GormDSN)
+}
+
+// Connect opens the configured database and applies the pool settings of cfg. While the database is
+// unreachable it retries up to cfg.ConnectAttempts times, doubling the wait from cfg.ConnectBackoff up
+// to cfg.ConnectMaxBackoff, and returns the last error once the attempts are used up or ctx is done.
+func Connect(ctx context.Context, cfg configs.DB) (*gorm.DB, error) {
+	dialector := Dialector(cfg)
+	attempts := max(cfg.ConnectAttempts, 1)
+	backoff := cfg.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		gormDB, err := open(ctx, dialector, cfg)
+		if err == nil {
+			return gormDB, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+
+		log.Warn().Err(err).Int("attempt", attempt).Dur("retry_in", backoff).Msg("Database unreachable, retrying")
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up connecting to the database: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, max(cfg.ConnectMaxBackoff, cfg.ConnectBackoff))
+	}
+}
+
+// open makes one connection attempt and configures the pool.
+func open(ctx context.Context, dialector gorm.Dialector, cfg configs.DB) (*gorm.DB, error) {
+	gormDB, err := gorm.Open(dialector, &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == DriverSQLite {
+		// SQLite allows one writer at a time, and an in-memory database only lives as long as its
+		// connection, so a single connection is kept open for the life of the process.
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return gormDB, nil
+}
+
+// PoolStats summarises the connection pool of gormDB for the readiness report.
+func PoolStats(gormDB *gorm.DB) map[string]any {
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil
+	}
+	stats := sqlDB.Stats()
+	return map[string]any{
+		"max_open":      stats.MaxOpenConnections,
+		"open":          stats.OpenConnections,
+		"in_use":        stats.InUse,
+		"idle":          stats.Idle,
+		"wait_count":    stats.WaitCount,
+		"wait_duration": stats.WaitDuration.String(),
+	}
+}
@@ -1,36 +1,206 @@
-// Package db handles database migrations.
+// Package db handles database connections and migrations.
 package db
 
 import (
+	"context"
+	"database/sql"
 	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"pack_optimizer/configs"
+	"path"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
 )
 
-//go:embed migrations/*
+// Each driver has its own migration set under migrations/<driver>, with the same versions.
+//
+//go:embed migrations
 var migrationsFS embed.FS
 
-// RunMigrations executes all pending SQL migration files.
-func RunMigrations(databaseURL string) {
+// Migrator applies and rolls back the migration set of the configured driver.
+type Migrator struct {
+	m      *migrate.Migrate
+	src    source.Driver
+	driver string
+}
+
+// MigrationStatus describes the schema version of a database.
+type MigrationStatus struct {
+	Current uint // Last applied version; 0 when nothing is applied
+	Dirty   bool // The last migration failed halfway and must be fixed and forced
+	Latest  uint // Highest version embedded in the binary
+}
+
+// NewMigrator creates a Migrator for cfg.Driver. SQLite migrations run on gormDB itself, so an
+// in-memory database is migrated in place; Postgres migrations use cfg.MigrateDSN.
+func NewMigrator(cfg configs.DB, gormDB *gorm.DB) (*Migrator, error) {
 	// Create an iofs source driver from the embedded filesystem
-	source, err := iofs.New(migrationsFS, "migrations")
+	src, err := iofs.New(migrationsFS, path.Join("migrations", cfg.Driver))
+	if err != nil {
+		return nil, fmt.Errorf("could not create iofs source driver: %w", err)
+	}
+
+	m, err := newMigrate(cfg, gormDB, src)
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed to create migration instance: %w", err)
+	}
+	return &Migrator{m: m, src: src, driver: cfg.Driver}, nil
+}
+
+// Up applies every pending migration. It succeeds when there is nothing to apply.
+func (mg *Migrator) Up() error {
+	return ignoreNoChange(mg.m.Up())
+}
+
+// Down rolls back the last steps migrations.
+func (mg *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be greater than 0, got %d", steps)
+	}
+	return ignoreNoChange(mg.m.Steps(-steps))
+}
+
+// Goto migrates up or down to version.
+func (mg *Migrator) Goto(version uint) error {
+	return ignoreNoChange(mg.m.Migrate(version))
+}
+
+// Force records version as applied and clears the dirty flag without running any migration.
+// -1 records that no migration is applied.
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Status reports the applied and the latest version.
+func (mg *Migrator) Status() (MigrationStatus, error) {
+	latest, err := LatestVersion(mg.driver)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	current, dirty, err := mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{Latest: latest}, nil
+	}
 	if err != nil {
-		log.Fatal().Err(err).Msg("Could not create iofs source driver")
+		return MigrationStatus{}, fmt.Errorf("failed to read migration version: %w", err)
 	}
+	return MigrationStatus{Current: current, Dirty: dirty, Latest: latest}, nil
+}
+
+// Close releases the migration source. The SQLite database belongs to the GORM pool, so it stays open.
+func (mg *Migrator) Close() error {
+	if mg.driver == DriverSQLite {
+		return mg.src.Close()
+	}
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
 
-	// Pass the iofs source driver to migrate.New
-	m, err := migrate.NewWithSourceInstance("iofs", source, databaseURL)
+// RunMigrations executes all pending SQL migration files of the configured driver.
+func RunMigrations(cfg configs.DB, gormDB *gorm.DB) error {
+	migrator, err := NewMigrator(cfg, gormDB)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create migration instance")
+		return err
 	}
+	defer migrator.Close()
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal().Err(err).Msg("Failed to apply database migrations")
+	if err := migrator.Up(); err != nil {
+		return fmt.Errorf("failed to apply database migrations: %w", err)
 	}
 
-	log.Info().Msg("Database migrations applied successfully")
+	log.Info().Str("driver", cfg.Driver).Msg("Database migrations applied successfully")
+	return nil
+}
+
+// ignoreNoChange treats migrate.ErrNoChange as success.
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// newMigrate creates a migrate instance for the configured driver.
+func newMigrate(cfg configs.DB, gormDB *gorm.DB, src source.Driver) (*migrate.Migrate, error) {
+	if cfg.Driver != DriverSQLite {
+		return migrate.NewWithSourceInstance("iofs", src, cfg.MigrateDSN)
+	}
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	instance, err := sqlite3.WithInstance(sqlDB, &sqlite3.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", src, DriverSQLite, instance)
+}
+
+// LatestVersion returns the highest migration version of driver embedded in the binary.
+func LatestVersion(driver string) (uint, error) {
+	source, err := iofs.New(migrationsFS, path.Join("migrations", driver))
+	if err != nil {
+		return 0, fmt.Errorf("could not create iofs source driver: %w", err)
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	if err != nil {
+		return 0, fmt.Errorf("could not read first migration: %w", err)
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("could not read migration after version %d: %w", version, err)
+		}
+		version = next
+	}
+}
+
+// CheckVersion returns an error unless gormDB is migrated to the latest version of driver and the
+// last migration completed.
+func CheckVersion(ctx context.Context, driver string, gormDB *gorm.DB) error {
+	latest, err := LatestVersion(driver)
+	if err != nil {
+		return err
+	}
+	current, dirty, err := CurrentVersion(ctx, gormDB)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", current)
+	}
+	if current != latest {
+		return fmt.Errorf("database is at version %d, latest is %d", current, latest)
+	}
+	return nil
+}
+
+// CurrentVersion reads the version golang-migrate recorded in the schema_migrations table. It is 0
+// when the table is empty, as it is before the first migration is applied.
+func CurrentVersion(ctx context.Context, gormDB *gorm.DB) (version uint, dirty bool, err error) {
+	row := gormDB.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Row()
+	err = row.Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
 }
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS calculations (
+    id UUID PRIMARY KEY,
+    quantity INTEGER NOT NULL,
+    exact_only BOOLEAN NOT NULL DEFAULT FALSE,
+    total_items INTEGER NOT NULL,
+    total_packs INTEGER NOT NULL,
+    difference INTEGER NOT NULL,
+    packs JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_calculations_created_at ON calculations (created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE calculations DROP COLUMN IF EXISTS tolerance_unit;
+ALTER TABLE calculations DROP COLUMN IF EXISTS tolerance_over;
+ALTER TABLE calculations DROP COLUMN IF EXISTS tolerance_under;
@@ -0,0 +1,3 @@
+ALTER TABLE calculations ADD COLUMN IF NOT EXISTS tolerance_under DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE calculations ADD COLUMN IF NOT EXISTS tolerance_over DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE calculations ADD COLUMN IF NOT EXISTS tolerance_unit VARCHAR(16) NOT NULL DEFAULT '';
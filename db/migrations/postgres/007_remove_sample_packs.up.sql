@@ -0,0 +1,4 @@
+-- Remove the sample sizes 001 inserted, unless the pack set has been changed since
+DELETE FROM packs
+WHERE size IN (250, 500, 1000, 2000, 5000)
+  AND NOT EXISTS (SELECT 1 FROM packs WHERE size NOT IN (250, 500, 1000, 2000, 5000));
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS calculations (
+    id CHAR(36) PRIMARY KEY,
+    quantity INTEGER NOT NULL,
+    exact_only BOOLEAN NOT NULL DEFAULT FALSE,
+    total_items INTEGER NOT NULL,
+    total_packs INTEGER NOT NULL,
+    difference INTEGER NOT NULL,
+    packs TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_calculations_created_at ON calculations (created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE calculations DROP COLUMN tolerance_unit;
+ALTER TABLE calculations DROP COLUMN tolerance_over;
+ALTER TABLE calculations DROP COLUMN tolerance_under;
@@ -0,0 +1,3 @@
+ALTER TABLE calculations ADD COLUMN tolerance_under DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE calculations ADD COLUMN tolerance_over DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE calculations ADD COLUMN tolerance_unit VARCHAR(16) NOT NULL DEFAULT '';
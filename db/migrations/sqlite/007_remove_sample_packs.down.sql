@@ -0,0 +1,5 @@
+-- Put the sample sizes back into an empty pack set
+WITH sample(size) AS (VALUES (250), (500), (1000), (2000), (5000))
+INSERT INTO packs (size)
+SELECT size FROM sample
+WHERE NOT EXISTS (SELECT 1 FROM packs);
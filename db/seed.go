@@ -0,0 +1,79 @@
+package db
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Seed files hold the sample data of one environment, named seeds/<env>.yaml, .yml or .json.
+// Migrations only create the schema, so environments without a seed file start empty.
+//
+//go:embed seeds
+var seedsFS embed.FS
+
+// seedExtensions lists the seed file formats in the order they are looked up.
+var seedExtensions = []string{".yaml", ".yml", ".json"}
+
+// ErrNoSeed is returned by LoadSeed when an environment has no seed file.
+var ErrNoSeed = errors.New("no seed file for environment")
+
+// Seed is the data written by the seed command.
+type Seed struct {
+	Packs []int `yaml:"packs" json:"packs"` // Pack sizes on offer
+}
+
+// LoadSeed returns the embedded seed of env.
+func LoadSeed(env string) (Seed, error) {
+	for _, ext := range seedExtensions {
+		name := path.Join("seeds", env+ext)
+		data, err := seedsFS.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Seed{}, err
+		}
+		return parseSeed(name, data)
+	}
+	return Seed{}, fmt.Errorf("%w %q", ErrNoSeed, env)
+}
+
+// ReadSeedFile reads a YAML or JSON seed file from disk.
+func ReadSeedFile(name string) (Seed, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return Seed{}, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	return parseSeed(name, data)
+}
+
+// parseSeed decodes data by the extension of name and rejects unknown fields.
+func parseSeed(name string, data []byte) (Seed, error) {
+	var seed Seed
+	var err error
+	switch filepath.Ext(name) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&seed)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&seed)
+	default:
+		return Seed{}, fmt.Errorf("seed file %s must be .yaml, .yml or .json", name)
+	}
+	if err != nil {
+		return Seed{}, fmt.Errorf("failed to parse seed file %s: %w", name, err)
+	}
+	return seed, nil
+}
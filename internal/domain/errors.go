@@ -1,5 +1,69 @@
 package domain
 
-import "errors"
+// Code is a stable, machine-readable identifier for a class of domain errors.
+// Clients may rely on it, so existing values must never change.
+type Code string
 
-var ErrNoPacksAvailable = errors.New("no packs available")
+const (
+	CodePacksUnavailable    Code = "PACKS_UNAVAILABLE"
+	CodeQuantityOutOfRange  Code = "QUANTITY_OUT_OF_RANGE"
+	CodeNoExactFit          Code = "NO_EXACT_FIT"
+	CodeOutsideTolerance    Code = "OUTSIDE_TOLERANCE"
+	CodeUnknownPackSize     Code = "UNKNOWN_PACK_SIZE"
+	CodeInvalidCombination  Code = "INVALID_COMBINATION"
+	CodeInvalidRange        Code = "INVALID_RANGE"
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeAPIKeyNotFound      Code = "API_KEY_NOT_FOUND"
+	CodeInvalidRole         Code = "INVALID_ROLE"
+	CodeOverloaded          Code = "SERVER_OVERLOADED"
+	CodeSolverBudget        Code = "SOLVER_BUDGET_EXCEEDED"
+	CodeInvalidPackSet      Code = "INVALID_PACK_SET"
+	CodeCalculationNotFound Code = "CALCULATION_NOT_FOUND"
+)
+
+// Error is the base type of every domain error. The sentinels below are *Error values,
+// so they can be matched with errors.Is, and errors.As(err, *Error) recovers the code of
+// any wrapped domain error.
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var ErrNoPacksAvailable = &Error{Code: CodePacksUnavailable, Message: "no packs available"}
+
+var ErrQuantityOutOfRange = &Error{Code: CodeQuantityOutOfRange, Message: "order quantity must be greater than 0"}
+
+var ErrNoExactFit = &Error{Code: CodeNoExactFit, Message: "no exact pack combination available"}
+
+var ErrOutsideTolerance = &Error{Code: CodeOutsideTolerance, Message: "no pack combination within tolerance"}
+
+var ErrUnknownPackSize = &Error{Code: CodeUnknownPackSize, Message: "unknown pack size"}
+
+var ErrInvalidCombination = &Error{Code: CodeInvalidCombination, Message: "invalid pack combination"}
+
+var ErrInvalidRange = &Error{Code: CodeInvalidRange, Message: "invalid quantity range"}
+
+var ErrUnauthorized = &Error{Code: CodeUnauthorized, Message: "authentication required"}
+
+var ErrInvalidAPIKey = &Error{Code: CodeUnauthorized, Message: "invalid or revoked API key"}
+
+var ErrInvalidToken = &Error{Code: CodeUnauthorized, Message: "invalid bearer token"}
+
+var ErrForbidden = &Error{Code: CodeForbidden, Message: "insufficient permissions"}
+
+var ErrAPIKeyNotFound = &Error{Code: CodeAPIKeyNotFound, Message: "API key not found"}
+
+var ErrInvalidRole = &Error{Code: CodeInvalidRole, Message: "invalid role"}
+
+var ErrInvalidPackSet = &Error{Code: CodeInvalidPackSet, Message: "invalid pack set"}
+
+var ErrCalculationNotFound = &Error{Code: CodeCalculationNotFound, Message: "calculation not found"}
+
+var ErrSolverBudgetExceeded = &Error{Code: CodeSolverBudget, Message: "solver explored too many combinations"}
+
+var ErrOverloaded = &Error{Code: CodeOverloaded, Message: "server is at capacity, retry later"}
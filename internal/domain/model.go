@@ -1,7 +1,10 @@
 // Package domain defines the core data structures and interfaces for the application.
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Pack struct {
 	ID   uint `gorm:"primaryKey"`
@@ -11,3 +14,127 @@ type Pack struct {
 type PackRepository interface {
 	GetAllPacks(ctx context.Context) ([]Pack, error)
 }
+
+// PackSetRepository reads and changes the pack sizes on offer.
+type PackSetRepository interface {
+	PackRepository
+	// UpdatePacks adds and removes sizes in one transaction. Adding a size that already exists
+	// or removing one that does not is a no-op, so repeating an update changes nothing.
+	UpdatePacks(ctx context.Context, add, remove []int) error
+}
+
+// Role grants access to a group of API routes.
+type Role string
+
+const (
+	RoleCalculator Role = "calculator" // May call the calculation endpoints
+	RolePackAdmin  Role = "pack-admin" // May manage pack sizes
+	RoleAuditor    Role = "auditor"    // May read calculation history
+)
+
+// Roles lists every role that can be assigned to an API key.
+var Roles = []Role{RoleCalculator, RolePackAdmin, RoleAuditor}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string // API key ID or token subject; unique per caller
+	Name    string // API key name or token subject, shown to people
+	Roles   []Role
+	Tenant  string
+	APIKey  bool // Authenticated with an API key rather than a token
+}
+
+// HasRole reports whether the principal holds at least one of the given roles.
+func (p Principal) HasRole(roles ...Role) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// APIKey is an issued API key. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null"`
+	Prefix    string `gorm:"not null"`             // First characters of the key, shown to identify it
+	KeyHash   string `gorm:"uniqueIndex;not null"` // Hex-encoded SHA-256 of the full key
+	Role      Role   `gorm:"not null"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uint, revokedAt time.Time) error
+}
+
+// Calculation is a recorded pack calculation, kept so it can be reviewed and shared later.
+type Calculation struct {
+	ID         string               `gorm:"primaryKey"` // Random UUID, used in shareable links
+	Quantity   int                  `gorm:"not null"`
+	ExactOnly  bool                 `gorm:"not null"`
+	Tolerance  CalculationTolerance `gorm:"embedded;embeddedPrefix:tolerance_"` // Zero when the calculation had no tolerance
+	TotalItems int                  `gorm:"not null"`
+	TotalPacks int                  `gorm:"not null"`
+	Difference int                  `gorm:"not null"` // Shipped minus ordered items, negative when under
+	Packs      []CalculationPack    `gorm:"serializer:json;not null"`
+	CreatedAt  time.Time            `gorm:"index;not null"`
+}
+
+// CalculationTolerance is the window around the ordered quantity a Calculation was allowed to ship in.
+type CalculationTolerance struct {
+	Under float64 `gorm:"not null"` // How far below the ordered quantity the packs could fall
+	Over  float64 `gorm:"not null"` // How far above the ordered quantity the packs could go
+	Unit  string  `gorm:"not null"` // absolute or percent; empty when there was no tolerance
+}
+
+// CalculationPack is the number of packs of one size used by a Calculation.
+type CalculationPack struct {
+	Size  int `json:"size"`
+	Count int `json:"count"`
+}
+
+// Outcome classifies a Calculation by how the shipped items compare to the ordered quantity.
+type Outcome string
+
+const (
+	OutcomeExact Outcome = "exact" // Shipped exactly the ordered quantity
+	OutcomeOver  Outcome = "over"  // Shipped more than ordered
+	OutcomeUnder Outcome = "under" // Shipped less than ordered, within a tolerance
+)
+
+// Outcome returns how the shipped items of c compare to the ordered quantity.
+func (c Calculation) Outcome() Outcome {
+	switch {
+	case c.Difference > 0:
+		return OutcomeOver
+	case c.Difference < 0:
+		return OutcomeUnder
+	}
+	return OutcomeExact
+}
+
+// CalculationFilter selects recorded calculations. Zero fields do not filter.
+type CalculationFilter struct {
+	MinQuantity int
+	MaxQuantity int
+	From        time.Time // Recorded at or after
+	To          time.Time // Recorded before
+	Outcome     Outcome
+	Limit       int
+	Offset      int
+}
+
+type CalculationRepository interface {
+	CreateCalculation(ctx context.Context, calculation *Calculation) error
+	GetCalculation(ctx context.Context, id string) (Calculation, error)
+	// ListCalculations returns the calculations matching filter, most recent first.
+	ListCalculations(ctx context.Context, filter CalculationFilter) ([]Calculation, error)
+	// DeleteCalculationsOlderThan deletes the calculations recorded before cutoff and returns how many there were.
+	DeleteCalculationsOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,228 @@
+// Package adminhandler provides the server-rendered admin pages for managing pack sizes.
+package adminhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/usecase/packsetusecase"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// layout is the template every admin page is rendered into.
+const layout = "layout"
+
+// packsPath is the admin page listing the pack sizes; every change redirects back to it.
+const packsPath = "/admin/packs"
+
+// notices holds the message shown on the list page after a change, keyed by the done query parameter.
+var notices = map[string]string{
+	"added":   "Pack size %s was added.",
+	"updated": "Pack size was changed to %s.",
+	"removed": "Pack size %s was removed.",
+}
+
+// PackAdminService is the pack set use case as seen by AdminHandler.
+type PackAdminService interface {
+	Sizes(ctx context.Context) ([]int, error)
+	AddSize(ctx context.Context, size int) error
+	PlanReplace(ctx context.Context, old, size int) (packsetusecase.Diff, error)
+	ReplaceSize(ctx context.Context, old, size int) (packsetusecase.Diff, error)
+	PlanRemove(ctx context.Context, size int) (packsetusecase.Diff, error)
+	RemoveSize(ctx context.Context, size int) (packsetusecase.Diff, error)
+}
+
+type AdminHandler struct {
+	packSetUseCase PackAdminService
+}
+
+func NewAdminHandler(packSetUseCase PackAdminService) *AdminHandler {
+	return &AdminHandler{packSetUseCase: packSetUseCase}
+}
+
+// ListPacks renders the pack sizes with a form to add one.
+func (h *AdminHandler) ListPacks(c *fiber.Ctx) error {
+	data := fiber.Map{}
+	if format, ok := notices[c.Query("done")]; ok {
+		if _, err := strconv.Atoi(c.Query("size")); err == nil {
+			data["Notice"] = fmt.Sprintf(format, c.Query("size"))
+		}
+	}
+	return h.renderList(c, fiber.StatusOK, data)
+}
+
+// AddPack adds the posted pack size. Invalid sizes are shown next to the form.
+func (h *AdminHandler) AddPack(c *fiber.Ctx) error {
+	var form PackForm
+	if err := c.BodyParser(&form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	size, err := parseSize(form.Size)
+	if err == nil {
+		err = h.packSetUseCase.AddSize(c.UserContext(), size)
+	}
+	if isFormError(err) {
+		return h.renderList(c, fiber.StatusUnprocessableEntity, fiber.Map{"Value": form.Size, "FieldError": formMessage(err)})
+	}
+	if err != nil {
+		return err
+	}
+	return redirectDone(c, "added", size)
+}
+
+// EditPack renders the form to change one pack size.
+func (h *AdminHandler) EditPack(c *fiber.Ctx) error {
+	old, err := h.storedSize(c)
+	if err != nil {
+		return err
+	}
+	return h.render(c, fiber.StatusOK, "admin_pack_edit", fiber.Map{"Size": old, "Value": strconv.Itoa(old)})
+}
+
+// UpdatePack changes one pack size. Orders can no longer be packed with the old size, so the
+// change is shown for confirmation first and only applied when the confirmation is posted.
+func (h *AdminHandler) UpdatePack(c *fiber.Ctx) error {
+	old, err := h.storedSize(c)
+	if err != nil {
+		return err
+	}
+	var form PackForm
+	if err := c.BodyParser(&form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	size, err := parseSize(form.Size)
+	var diff packsetusecase.Diff
+	if err == nil {
+		if form.Confirm {
+			diff, err = h.packSetUseCase.ReplaceSize(c.UserContext(), old, size)
+		} else {
+			diff, err = h.packSetUseCase.PlanReplace(c.UserContext(), old, size)
+		}
+	}
+	if isFormError(err) {
+		return h.render(c, fiber.StatusUnprocessableEntity, "admin_pack_edit", fiber.Map{
+			"Size":       old,
+			"Value":      form.Size,
+			"FieldError": formMessage(err),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if !form.Confirm && diff.Changed() {
+		return h.render(c, fiber.StatusOK, "admin_confirm", fiber.Map{
+			"Title":  fmt.Sprintf("Change pack size %d to %d?", old, size),
+			"Action": fmt.Sprintf("%s/%d/edit", packsPath, old),
+			"Value":  form.Size,
+			"Diff":   diff,
+		})
+	}
+	return redirectDone(c, "updated", size)
+}
+
+// RemovePack removes one pack size after the removal was confirmed.
+func (h *AdminHandler) RemovePack(c *fiber.Ctx) error {
+	size, err := h.storedSize(c)
+	if err != nil {
+		return err
+	}
+	var form PackForm
+	if err := c.BodyParser(&form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	var diff packsetusecase.Diff
+	if form.Confirm {
+		diff, err = h.packSetUseCase.RemoveSize(c.UserContext(), size)
+	} else {
+		diff, err = h.packSetUseCase.PlanRemove(c.UserContext(), size)
+	}
+	if isFormError(err) {
+		return h.renderList(c, fiber.StatusUnprocessableEntity, fiber.Map{"Error": formMessage(err)})
+	}
+	if err != nil {
+		return err
+	}
+	if !form.Confirm {
+		return h.render(c, fiber.StatusOK, "admin_confirm", fiber.Map{
+			"Title":  fmt.Sprintf("Remove pack size %d?", size),
+			"Action": fmt.Sprintf("%s/%d/delete", packsPath, size),
+			"Diff":   diff,
+		})
+	}
+	return redirectDone(c, "removed", size)
+}
+
+// storedSize returns the pack size named in the path, or a 404 if it is not stored.
+func (h *AdminHandler) storedSize(c *fiber.Ctx) (int, error) {
+	size, err := c.ParamsInt("size")
+	if err != nil {
+		return 0, fiber.ErrNotFound
+	}
+	sizes, err := h.packSetUseCase.Sizes(c.UserContext())
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(sizes, size) {
+		return 0, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("pack size %d does not exist", size))
+	}
+	return size, nil
+}
+
+// renderList renders the list page with data on top of the stored sizes.
+func (h *AdminHandler) renderList(c *fiber.Ctx, status int, data fiber.Map) error {
+	sizes, err := h.packSetUseCase.Sizes(c.UserContext())
+	if err != nil {
+		return err
+	}
+	data["Sizes"] = sizes
+	return h.render(c, status, "admin_packs", data)
+}
+
+// render renders an admin page into the layout, adding what every page needs.
+func (h *AdminHandler) render(c *fiber.Ctx, status int, name string, data fiber.Map) error {
+	data["CSRFField"] = middlewares.CSRFField
+	data["CSRFToken"] = middlewares.CSRFTokenFromCtx(c)
+	if principal, ok := middlewares.PrincipalFromCtx(c); ok {
+		data["User"] = principal.Name
+	}
+	return c.Status(status).Render(name, data, layout)
+}
+
+// redirectDone sends the browser back to the list page, which reports the change.
+// It answers with 303 so reloading the page does not post the form again.
+func redirectDone(c *fiber.Ctx, done string, size int) error {
+	query := url.Values{"done": {done}, "size": {strconv.Itoa(size)}}
+	return c.Redirect(packsPath+"?"+query.Encode(), fiber.StatusSeeOther)
+}
+
+// errNotANumber is returned for a size that is not a whole number.
+var errNotANumber = fmt.Errorf("%w: pack size must be a whole number", domain.ErrInvalidPackSet)
+
+func parseSize(value string) (int, error) {
+	size, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, errNotANumber
+	}
+	return size, nil
+}
+
+// isFormError reports whether err is a mistake in the submitted form, shown on the page
+// rather than as an error response.
+func isFormError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidPackSet) || errors.Is(err, domain.ErrUnknownPackSize)
+}
+
+// formMessage returns the message of a form error as a sentence, without the generic "invalid pack set" prefix.
+func formMessage(err error) string {
+	message := strings.TrimPrefix(err.Error(), domain.ErrInvalidPackSet.Message+": ")
+	return strings.ToUpper(message[:1]) + message[1:] + "."
+}
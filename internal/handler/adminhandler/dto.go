@@ -0,0 +1,8 @@
+package adminhandler
+
+// PackForm is the form posted to add, edit or remove a pack size. Size is kept as text
+// so the value the user typed can be shown again next to its validation error.
+type PackForm struct {
+	Size    string `form:"size"`
+	Confirm bool   `form:"confirm"` // Set by the confirmation page; destructive changes are only applied with it
+}
@@ -1,6 +1,113 @@
 // Package customerrrors defines custom error types used across the application.
 package customerrrors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"pack_optimizer/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Codes for errors raised by the transport layer itself. Domain errors keep their domain.Code.
+const (
+	CodeInvalidRequest   domain.Code = "INVALID_REQUEST"
+	CodeValidationFailed domain.Code = "VALIDATION_FAILED"
+	CodeNotFound         domain.Code = "NOT_FOUND"
+	CodeMethodNotAllowed domain.Code = "METHOD_NOT_ALLOWED"
+	CodeInternal         domain.Code = "INTERNAL_ERROR"
+	CodeRateLimited      domain.Code = "RATE_LIMITED"
+	CodeCostTooHigh      domain.Code = "COST_TOO_HIGH"
+	CodeIdempotencyReuse domain.Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyBusy  domain.Code = "IDEMPOTENCY_KEY_IN_USE"
+)
 
 var ErrUnexpected = errors.New("unexpected error occurred")
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`   // Path of the field as sent by the client, e.g. "tolerance.unit"
+	Rule    string `json:"rule"`    // Validation rule that failed, e.g. "min"
+	Message string `json:"message"` // Human-readable explanation
+}
+
+// APIError is an error that already knows how it must be rendered as a problem details response.
+// Handlers return it for failures detected in the transport layer; everything else is converted
+// by ToAPIError in the central ErrorHandler.
+type APIError struct {
+	Status     int
+	Code       domain.Code
+	Detail     string
+	Fields     []FieldError
+	Extensions map[string]any // Extra members added to the response body
+	RetryAfter int            // Seconds sent in the Retry-After header, if greater than 0
+	Err        error          // Underlying cause, logged but never sent to the client
+}
+
+func (e *APIError) Error() string {
+	return e.Detail
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidRequest wraps an error returned while parsing a request.
+func InvalidRequest(err error) *APIError {
+	return &APIError{
+		Status: fiber.StatusBadRequest,
+		Code:   CodeInvalidRequest,
+		Detail: "request could not be parsed",
+		Err:    err,
+	}
+}
+
+// InvalidField reports a single invalid field that is not covered by struct validation tags.
+func InvalidField(field, rule, message string) *APIError {
+	return &APIError{
+		Status: fiber.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: "request validation failed",
+		Fields: []FieldError{{Field: field, Rule: rule, Message: message}},
+	}
+}
+
+// RateLimited reports a request rejected by the named rate limit policy.
+func RateLimited(policy string, retryAfterSeconds int) *APIError {
+	return &APIError{
+		Status:     fiber.StatusTooManyRequests,
+		Code:       CodeRateLimited,
+		Detail:     fmt.Sprintf("%s limit exceeded, retry in %d seconds", policy, retryAfterSeconds),
+		Extensions: map[string]any{"retry_after": retryAfterSeconds},
+		RetryAfter: retryAfterSeconds,
+	}
+}
+
+// CostTooHigh reports a request that costs more than the named policy's burst. It could never
+// be admitted, so it is rejected outright instead of being told to retry.
+func CostTooHigh(policy string, cost, burst float64) *APIError {
+	return &APIError{
+		Status:     fiber.StatusRequestEntityTooLarge,
+		Code:       CodeCostTooHigh,
+		Detail:     fmt.Sprintf("request costs %d %s tokens, more than the %d a client may use at once", int64(cost), policy, int64(burst)),
+		Extensions: map[string]any{"cost": int64(cost), "max_cost": int64(burst)},
+	}
+}
+
+// IdempotencyKeyReused reports an idempotency key that was first used for a different request.
+func IdempotencyKeyReused() *APIError {
+	return &APIError{
+		Status: fiber.StatusUnprocessableEntity,
+		Code:   CodeIdempotencyReuse,
+		Detail: "idempotency key was already used for a different request",
+	}
+}
+
+// IdempotencyKeyInUse reports an idempotency key whose first request has not finished yet.
+func IdempotencyKeyInUse() *APIError {
+	return &APIError{
+		Status: fiber.StatusConflict,
+		Code:   CodeIdempotencyBusy,
+		Detail: "a request with this idempotency key is still being processed",
+	}
+}
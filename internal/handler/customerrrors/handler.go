@@ -1,16 +1,19 @@
 package customerrrors
 
 import (
+	"pack_optimizer/pkg/logpkg"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
 )
 
-// ErrorHandler is a Central error handler
+// ProblemContentType is the media type of RFC 7807 problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// ErrorHandler is a Central error handler. It renders every error as an RFC 7807 problem details
+// response carrying a stable code and the request ID.
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-	}
+	apiErr := ToAPIError(err)
 
 	// Fetch request ID from context
 	reqID, tErr := c.Locals("request_id").(string)
@@ -22,20 +25,38 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 		}
 	}
 
-	// Log the error with structured fields
-	log.Error().
+	// Log the error through the request-scoped logger, which already carries the request ID,
+	// method and path when LoggerMiddleware ran.
+	event := logpkg.FromContext(c.UserContext()).Warn()
+	if apiErr.Status >= fiber.StatusInternalServerError {
+		event = logpkg.FromContext(c.UserContext()).Error()
+	}
+	event.
 		Err(err).
-		Str("request_id", reqID).
-		Str("method", c.Method()).
-		Str("path", c.Path()).
+		Str("code", string(apiErr.Code)).
 		Str("ip", c.IP()).
 		Str("user_agent", c.Get("User-Agent")).
-		Int("status_code", code).
+		Int("status_code", apiErr.Status).
 		Msg("request failed")
 
-	// Respond with generic error
-	return c.Status(code).JSON(fiber.Map{
-		"error":  err.Error(),
-		"req_id": reqID,
-	})
+	body := fiber.Map{
+		"type":       problemType(apiErr.Code),
+		"title":      problemTitle(apiErr.Code),
+		"status":     apiErr.Status,
+		"detail":     apiErr.Detail,
+		"instance":   c.OriginalURL(),
+		"code":       apiErr.Code,
+		"request_id": reqID,
+	}
+	if len(apiErr.Fields) > 0 {
+		body["errors"] = apiErr.Fields
+	}
+	for key, value := range apiErr.Extensions {
+		body[key] = value
+	}
+
+	if apiErr.RetryAfter > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(apiErr.RetryAfter))
+	}
+	return c.Status(apiErr.Status).JSON(body, ProblemContentType)
 }
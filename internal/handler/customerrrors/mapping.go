@@ -0,0 +1,173 @@
+package customerrrors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/packusecase"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// statusByCode maps every domain error code to the HTTP status it is served with.
+// A domain code missing here is served as a 500, so new codes must be added.
+var statusByCode = map[domain.Code]int{
+	domain.CodePacksUnavailable:    fiber.StatusNotFound,
+	domain.CodeQuantityOutOfRange:  fiber.StatusBadRequest,
+	domain.CodeNoExactFit:          fiber.StatusUnprocessableEntity,
+	domain.CodeOutsideTolerance:    fiber.StatusUnprocessableEntity,
+	domain.CodeUnknownPackSize:     fiber.StatusUnprocessableEntity,
+	domain.CodeInvalidCombination:  fiber.StatusBadRequest,
+	domain.CodeInvalidRange:        fiber.StatusBadRequest,
+	domain.CodeUnauthorized:        fiber.StatusUnauthorized,
+	domain.CodeForbidden:           fiber.StatusForbidden,
+	domain.CodeAPIKeyNotFound:      fiber.StatusNotFound,
+	domain.CodeInvalidRole:         fiber.StatusBadRequest,
+	domain.CodeOverloaded:          fiber.StatusServiceUnavailable,
+	domain.CodeSolverBudget:        fiber.StatusUnprocessableEntity,
+	domain.CodeInvalidPackSet:      fiber.StatusBadRequest,
+	domain.CodeCalculationNotFound: fiber.StatusNotFound,
+}
+
+// titles holds the short, fixed summary sent as the problem title for each code.
+var titles = map[domain.Code]string{
+	domain.CodePacksUnavailable:    "No pack sizes are configured",
+	domain.CodeQuantityOutOfRange:  "Quantity is out of range",
+	domain.CodeNoExactFit:          "No exact pack combination",
+	domain.CodeOutsideTolerance:    "No pack combination within tolerance",
+	domain.CodeUnknownPackSize:     "Unknown pack size",
+	domain.CodeInvalidCombination:  "Invalid pack combination",
+	domain.CodeInvalidRange:        "Invalid quantity range",
+	domain.CodeUnauthorized:        "Authentication required",
+	domain.CodeForbidden:           "Forbidden",
+	domain.CodeAPIKeyNotFound:      "API key not found",
+	domain.CodeInvalidRole:         "Invalid role",
+	domain.CodeOverloaded:          "Server overloaded",
+	domain.CodeSolverBudget:        "Solver budget exceeded",
+	domain.CodeInvalidPackSet:      "Invalid pack set",
+	domain.CodeCalculationNotFound: "Calculation not found",
+	CodeInvalidRequest:             "Request could not be parsed",
+	CodeValidationFailed:           "Request validation failed",
+	CodeNotFound:                   "Resource not found",
+	CodeMethodNotAllowed:           "Method not allowed",
+	CodeInternal:                   "Internal server error",
+	CodeRateLimited:                "Too many requests",
+	CodeCostTooHigh:                "Request costs more than the rate limit allows",
+	CodeIdempotencyReuse:           "Idempotency key reused",
+	CodeIdempotencyBusy:            "Idempotency key in use",
+}
+
+// ToAPIError converts any error returned by a handler into an APIError.
+// It is the single place where domain, validation and framework errors are mapped to responses.
+func ToAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return fromValidationErrors(validationErrs)
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status, ok := statusByCode[domainErr.Code]
+		if !ok {
+			status = fiber.StatusInternalServerError
+		}
+		apiErr = &APIError{Status: status, Code: domainErr.Code, Detail: err.Error(), Err: err}
+
+		var fitErr *packusecase.NoFitError
+		if errors.As(err, &fitErr) {
+			apiErr.Extensions = map[string]any{"nearest_above": fitErr.NearestAbove}
+			if fitErr.NearestBelow > 0 {
+				apiErr.Extensions["nearest_below"] = fitErr.NearestBelow
+			}
+		}
+
+		var overloadErr *packusecase.OverloadError
+		if errors.As(err, &overloadErr) {
+			apiErr.RetryAfter = max(int(math.Ceil(overloadErr.RetryAfter.Seconds())), 1)
+		}
+		return apiErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code := CodeInternal
+		switch {
+		case fiberErr.Code == fiber.StatusNotFound:
+			code = CodeNotFound
+		case fiberErr.Code == fiber.StatusMethodNotAllowed:
+			code = CodeMethodNotAllowed
+		case fiberErr.Code < fiber.StatusInternalServerError:
+			code = CodeInvalidRequest
+		}
+		return &APIError{Status: fiberErr.Code, Code: code, Detail: fiberErr.Message, Err: err}
+	}
+
+	return &APIError{
+		Status: fiber.StatusInternalServerError,
+		Code:   CodeInternal,
+		Detail: ErrUnexpected.Error(),
+		Err:    err,
+	}
+}
+
+// problemType returns the URI identifying a problem type, e.g. urn:pack-optimizer:problem:packs-unavailable.
+func problemType(code domain.Code) string {
+	return "urn:pack-optimizer:problem:" + strings.ReplaceAll(strings.ToLower(string(code)), "_", "-")
+}
+
+func problemTitle(code domain.Code) string {
+	if title, ok := titles[code]; ok {
+		return title
+	}
+	return titles[CodeInternal]
+}
+
+func fromValidationErrors(errs validator.ValidationErrors) *APIError {
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		// Drop the request struct name, keeping the path the client used, e.g. "tolerance.unit".
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		fields = append(fields, FieldError{Field: field, Rule: fe.Tag(), Message: fieldMessage(fe)})
+	}
+
+	return &APIError{
+		Status: fiber.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: "request validation failed",
+		Fields: fields,
+		Err:    errs,
+	}
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	collection := fe.Kind() == reflect.Map || fe.Kind() == reflect.Slice
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if collection {
+			return fmt.Sprintf("must contain at least %s entries", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if collection {
+			return fmt.Sprintf("must contain at most %s entries", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "gtefield":
+		return "must not be less than " + strings.ToLower(fe.Param())
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,81 @@
+// Package healthhandler provides liveness and readiness HTTP handlers.
+package healthhandler
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkTimeout bounds how long a single dependency check may take.
+const checkTimeout = 2 * time.Second
+
+// Check is a named readiness probe for one dependency. Run returns nil when the dependency is usable.
+// Details, if set, adds diagnostics such as connection pool stats to the report.
+type Check struct {
+	Name    string
+	Run     func(ctx context.Context) error
+	Details func() map[string]any
+}
+
+// ComponentStatus is the outcome of a single Check.
+type ComponentStatus struct {
+	Status  string         `json:"status"`            // "ok" or "fail"
+	Error   string         `json:"error,omitempty"`   // Why the check failed
+	Details map[string]any `json:"details,omitempty"` // Diagnostics from Check.Details
+}
+
+// Report is the body returned by the health endpoints.
+type Report struct {
+	Status     string                     `json:"status"` // "ok", "ready" or "not_ready"
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+type HealthHandler struct {
+	checks       []Check
+	shuttingDown func() bool
+}
+
+// NewHealthHandler creates a handler that runs checks on readiness probes.
+// shuttingDown reports whether the server has started a graceful shutdown.
+func NewHealthHandler(shuttingDown func() bool, checks ...Check) *HealthHandler {
+	return &HealthHandler{checks: checks, shuttingDown: shuttingDown}
+}
+
+// Liveness reports that the process is up and serving requests. It never touches dependencies.
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(Report{Status: "ok"})
+}
+
+// Readiness runs every dependency check and answers 503 if any of them fails
+// or if the server is shutting down.
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	report := Report{Status: "ready", Components: make(map[string]ComponentStatus, len(h.checks)+1)}
+
+	for _, check := range h.checks {
+		ctx, cancel := context.WithTimeout(c.UserContext(), checkTimeout)
+		err := check.Run(ctx)
+		cancel()
+
+		status := ComponentStatus{Status: "ok"}
+		if err != nil {
+			report.Status = "not_ready"
+			status = ComponentStatus{Status: "fail", Error: err.Error()}
+		}
+		if check.Details != nil {
+			status.Details = check.Details()
+		}
+		report.Components[check.Name] = status
+	}
+
+	if h.shuttingDown() {
+		report.Status = "not_ready"
+		report.Components["shutdown"] = ComponentStatus{Status: "fail", Error: "server is shutting down"}
+	}
+
+	if report.Status != "ready" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
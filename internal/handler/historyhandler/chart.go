@@ -0,0 +1,76 @@
+package historyhandler
+
+import (
+	"fmt"
+	"pack_optimizer/internal/domain"
+)
+
+// chartWidth is the width of the chart's drawing area in SVG user units.
+const chartWidth = 600.0
+
+// packColors fill the pack segments in turn; overage and shortage have colors of their own.
+var packColors = []string{"#4f46e5", "#818cf8", "#3730a3", "#a5b4fc", "#6366f1", "#312e81"}
+
+// chartBar is one segment of a bar in the chart.
+type chartBar struct {
+	X     float64
+	Width float64
+	Color string
+	Label string // Drawn inside the segment when it is wide enough
+	Title string // Tooltip
+}
+
+// chart is the view model of the chart of one calculation: a bar of the packs stacked by size,
+// and below it a bar of the ordered quantity extended by the overage. Both bars share one scale,
+// so the overage lines up with the part of the packs that was not ordered.
+type chart struct {
+	Packs     []chartBar
+	Order     []chartBar
+	QuantityX float64 // Position of the ordered quantity, marked across both bars
+	Scale     int     // Items at the right edge of the chart
+}
+
+func newChart(c domain.Calculation) chart {
+	scale := max(c.TotalItems, c.Quantity, 1)
+	x := func(items int) float64 { return float64(items) / float64(scale) * chartWidth }
+
+	ch := chart{QuantityX: x(c.Quantity), Scale: scale}
+	shipped := 0
+	for i, pack := range c.Packs {
+		items := pack.Size * pack.Count
+		ch.Packs = append(ch.Packs, chartBar{
+			X:     x(shipped),
+			Width: x(items),
+			Color: packColors[i%len(packColors)],
+			Label: fmt.Sprintf("%d × %d", pack.Count, pack.Size),
+			Title: fmt.Sprintf("%d × %d-item packs = %d items", pack.Count, pack.Size, items),
+		})
+		shipped += items
+	}
+	if c.Difference < 0 {
+		ch.Packs = append(ch.Packs, chartBar{
+			X:     x(shipped),
+			Width: x(-c.Difference),
+			Color: "#ef4444",
+			Label: fmt.Sprintf("%d short", -c.Difference),
+			Title: fmt.Sprintf("%d ordered items are not shipped", -c.Difference),
+		})
+	}
+
+	ch.Order = []chartBar{{
+		Width: x(c.Quantity),
+		Color: "#9ca3af",
+		Label: fmt.Sprintf("%d ordered", c.Quantity),
+		Title: fmt.Sprintf("%d items ordered", c.Quantity),
+	}}
+	if c.Difference > 0 {
+		ch.Order = append(ch.Order, chartBar{
+			X:     x(c.Quantity),
+			Width: x(c.Difference),
+			Color: "#f59e0b",
+			Label: fmt.Sprintf("+%d", c.Difference),
+			Title: fmt.Sprintf("%d items of overage", c.Difference),
+		})
+	}
+	return ch
+}
@@ -0,0 +1,11 @@
+package historyhandler
+
+// ListReq holds the filters of the history page. Dates are calendar days in UTC, both inclusive.
+type ListReq struct {
+	MinQuantity int    `query:"min_quantity" validate:"min=0"`
+	MaxQuantity int    `query:"max_quantity" validate:"min=0"`
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Outcome     string `query:"outcome" validate:"omitempty,oneof=exact over under"`
+	Page        int    `query:"page" validate:"min=0"` // 1-based; 0 is the first page
+}
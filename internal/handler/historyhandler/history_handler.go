@@ -0,0 +1,200 @@
+// Package historyhandler provides the server-rendered pages of the calculation history.
+package historyhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/pkg/validator"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// layout is the template every history page is rendered into.
+const layout = "layout"
+
+// pageSize is the number of calculations listed per page.
+const pageSize = 50
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "2006-01-02 15:04 UTC"
+)
+
+// HistoryService is the history use case as seen by HistoryHandler.
+type HistoryService interface {
+	Get(ctx context.Context, id string) (domain.Calculation, error)
+	List(ctx context.Context, filter domain.CalculationFilter) ([]domain.Calculation, error)
+}
+
+// row is one calculation in the history list.
+type row struct {
+	ID         string
+	RecordedAt string
+	Quantity   int
+	TotalItems int
+	TotalPacks int
+	Packs      string
+	Difference int
+	Outcome    domain.Outcome
+}
+
+type HistoryHandler struct {
+	historyUseCase HistoryService
+}
+
+func NewHistoryHandler(historyUseCase HistoryService) *HistoryHandler {
+	return &HistoryHandler{historyUseCase: historyUseCase}
+}
+
+// List renders the most recent calculations matching the filters in the query string.
+// Invalid filters are shown above the filter form instead of failing the page.
+func (h *HistoryHandler) List(c *fiber.Ctx) error {
+	var req ListReq
+	data := fiber.Map{"Filter": &req}
+	if err := c.QueryParser(&req); err != nil {
+		data["Errors"] = []string{"The filters could not be read: " + err.Error()}
+		return h.render(c, fiber.StatusBadRequest, "history", data)
+	}
+	if err := validator.Validate.Struct(req); err != nil {
+		data["Errors"] = filterErrors(err)
+		return h.render(c, fiber.StatusBadRequest, "history", data)
+	}
+
+	page := max(req.Page, 1)
+	filter := domain.CalculationFilter{
+		MinQuantity: req.MinQuantity,
+		MaxQuantity: req.MaxQuantity,
+		Outcome:     domain.Outcome(req.Outcome),
+		Limit:       pageSize + 1, // One more than shown tells whether there is a next page
+		Offset:      (page - 1) * pageSize,
+	}
+	if req.From != "" {
+		filter.From, _ = time.Parse(dateLayout, req.From)
+	}
+	if req.To != "" {
+		to, _ := time.Parse(dateLayout, req.To)
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	calculations, err := h.historyUseCase.List(c.UserContext(), filter)
+	if errors.Is(err, domain.ErrInvalidRange) {
+		data["Errors"] = []string{sentence(strings.TrimPrefix(err.Error(), domain.ErrInvalidRange.Message+": "))}
+		return h.render(c, fiber.StatusBadRequest, "history", data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(calculations) > pageSize {
+		calculations = calculations[:pageSize]
+		data["NextURL"] = pageURL(req, page+1)
+	}
+	if page > 1 {
+		data["PrevURL"] = pageURL(req, page-1)
+	}
+	rows := make([]row, len(calculations))
+	for i, calculation := range calculations {
+		rows[i] = newRow(calculation)
+	}
+	data["Rows"] = rows
+	data["Page"] = page
+	return h.render(c, fiber.StatusOK, "history", data)
+}
+
+// Show renders one calculation with its chart and the link to share it.
+func (h *HistoryHandler) Show(c *fiber.Ctx) error {
+	calculation, err := h.historyUseCase.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return h.render(c, fiber.StatusOK, "history_detail", fiber.Map{
+		"Calculation": newRow(calculation),
+		"Options":     options(calculation),
+		"Packs":       calculation.Packs,
+		"Chart":       newChart(calculation),
+		"ShareURL":    c.BaseURL() + "/history/" + url.PathEscape(calculation.ID),
+	})
+}
+
+// render renders a history page into the layout, adding what every page needs.
+func (h *HistoryHandler) render(c *fiber.Ctx, status int, name string, data fiber.Map) error {
+	if principal, ok := middlewares.PrincipalFromCtx(c); ok {
+		data["User"] = principal.Name
+	}
+	return c.Status(status).Render(name, data, layout)
+}
+
+func newRow(c domain.Calculation) row {
+	packs := make([]string, len(c.Packs))
+	for i, pack := range c.Packs {
+		packs[i] = fmt.Sprintf("%d × %d", pack.Count, pack.Size)
+	}
+	return row{
+		ID:         c.ID,
+		RecordedAt: c.CreatedAt.UTC().Format(timeLayout),
+		Quantity:   c.Quantity,
+		TotalItems: c.TotalItems,
+		TotalPacks: c.TotalPacks,
+		Packs:      strings.Join(packs, " + "),
+		Difference: c.Difference,
+		Outcome:    c.Outcome(),
+	}
+}
+
+// options describes the options a calculation was run with, e.g. "up to 5 items under and 10 items over".
+// It is empty for a calculation without options, which ships at least the ordered quantity.
+func options(c domain.Calculation) string {
+	if c.ExactOnly {
+		return "exact quantity only"
+	}
+	if c.Tolerance.Unit == "" {
+		return ""
+	}
+	unit := " items"
+	if c.Tolerance.Unit == "percent" {
+		unit = "%"
+	}
+	return fmt.Sprintf("up to %s%s under and %s%s over",
+		strconv.FormatFloat(c.Tolerance.Under, 'f', -1, 64), unit,
+		strconv.FormatFloat(c.Tolerance.Over, 'f', -1, 64), unit)
+}
+
+// pageURL returns the link to another page of the list, keeping the filters of req.
+func pageURL(req ListReq, page int) string {
+	query := url.Values{"page": {strconv.Itoa(page)}}
+	if req.MinQuantity > 0 {
+		query.Set("min_quantity", strconv.Itoa(req.MinQuantity))
+	}
+	if req.MaxQuantity > 0 {
+		query.Set("max_quantity", strconv.Itoa(req.MaxQuantity))
+	}
+	for key, value := range map[string]string{"from": req.From, "to": req.To, "outcome": req.Outcome} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return "/history?" + query.Encode()
+}
+
+// filterErrors returns one message per invalid filter, as the API reports them.
+func filterErrors(err error) []string {
+	apiErr := customerrrors.ToAPIError(err)
+	messages := make([]string, 0, len(apiErr.Fields))
+	for _, field := range apiErr.Fields {
+		messages = append(messages, sentence(field.Field+" "+field.Message))
+	}
+	return messages
+}
+
+// sentence capitalises message and ends it with a period.
+func sentence(message string) string {
+	return strings.ToUpper(message[:1]) + message[1:] + "."
+}
@@ -0,0 +1,156 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/pkg/logpkg"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHeader is the request header that carries an API key.
+const APIKeyHeader = "X-API-Key"
+
+// principalLocal is the fiber.Ctx locals key holding the authenticated domain.Principal.
+const principalLocal = "principal"
+
+// Authenticator resolves a credential, an API key or a bearer token, into the principal it belongs to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (domain.Principal, error)
+}
+
+// AuthMiddleware authenticates requests that carry an API key or a bearer token and stores the
+// principal in the request locals. Requests without credentials pass through unauthenticated;
+// RequireRole decides whether a route accepts them. Invalid credentials are always rejected.
+// tokens may be nil when no token issuer is configured, in which case bearer tokens are rejected.
+func AuthMiddleware(keys, tokens Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			principal domain.Principal
+			err       error
+		)
+		if key := c.Get(APIKeyHeader); key != "" {
+			principal, err = keys.Authenticate(c.UserContext(), key)
+			if err != nil {
+				c.Set(fiber.HeaderWWWAuthenticate, "ApiKey")
+				return err
+			}
+		} else if token, ok := bearerToken(c); ok {
+			if tokens == nil {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return domain.ErrInvalidToken
+			}
+			principal, err = tokens.Authenticate(c.UserContext(), token)
+			if err != nil {
+				if errors.Is(err, domain.ErrForbidden) {
+					c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="insufficient_scope"`)
+				} else {
+					c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				}
+				return err
+			}
+		} else {
+			return c.Next()
+		}
+
+		setPrincipal(c, principal)
+		return c.Next()
+	}
+}
+
+// BasicAuthMiddleware authenticates browser requests with HTTP Basic auth, taking the API key
+// from the password; the user name is ignored. Unlike AuthMiddleware it requires credentials,
+// and answers requests without valid ones with a Basic challenge so the browser prompts for them.
+func BasicAuthMiddleware(keys Authenticator, realm string) fiber.Handler {
+	challenge := `Basic realm="` + realm + `", charset="UTF-8"`
+	return func(c *fiber.Ctx) error {
+		key, ok := basicPassword(c)
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, challenge)
+			return domain.ErrUnauthorized
+		}
+		principal, err := keys.Authenticate(c.UserContext(), key)
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, challenge)
+			return err
+		}
+		setPrincipal(c, principal)
+		return c.Next()
+	}
+}
+
+// setPrincipal stores an authenticated principal in the request locals and its identity in the request logger.
+func setPrincipal(c *fiber.Ctx, principal domain.Principal) {
+	c.Locals(principalLocal, principal)
+	logger := logpkg.FromContext(c.UserContext()).With().
+		Str("subject", principal.Subject).
+		Str("name", principal.Name).
+		Str("tenant", principal.Tenant).
+		Logger()
+	c.SetUserContext(logger.WithContext(c.UserContext()))
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// basicPassword extracts the password of an "Authorization: Basic" header.
+func basicPassword(c *fiber.Ctx) (string, bool) {
+	scheme, encoded, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", false
+	}
+	_, password, ok := strings.Cut(string(decoded), ":")
+	return password, ok && password != ""
+}
+
+// PrincipalFromCtx returns the principal stored by AuthMiddleware, if the request was authenticated.
+func PrincipalFromCtx(c *fiber.Ctx) (domain.Principal, bool) {
+	principal, ok := c.Locals(principalLocal).(domain.Principal)
+	return principal, ok
+}
+
+// clientID identifies the caller for per-client state such as rate limits and idempotency records:
+// the API key or token subject when AuthMiddleware authenticated the request, the IP address otherwise.
+// API keys and tokens get separate namespaces, since a token's subject could look like a key's ID.
+func clientID(c *fiber.Ctx) string {
+	if principal, ok := PrincipalFromCtx(c); ok {
+		if principal.APIKey {
+			return "apikey:" + principal.Subject
+		}
+		return "principal:" + principal.Tenant + "/" + principal.Subject
+	}
+	return "ip:" + c.IP()
+}
+
+// RequireRole only lets requests through whose principal holds one of the given roles.
+// When allowAnonymous is set, requests without credentials are let through as well.
+func RequireRole(allowAnonymous bool, roles ...domain.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := PrincipalFromCtx(c)
+		if !ok {
+			if allowAnonymous {
+				return c.Next()
+			}
+			c.Set(fiber.HeaderWWWAuthenticate, "ApiKey, Bearer")
+			return domain.ErrUnauthorized
+		}
+		if !principal.HasRole(roles...) {
+			return domain.ErrForbidden
+		}
+		return c.Next()
+	}
+}
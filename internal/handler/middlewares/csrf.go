@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+)
+
+// CSRFField is the form field that carries the CSRF token of server-rendered forms.
+const CSRFField = "_csrf"
+
+// csrfLocal is the fiber.Ctx locals key holding the CSRF token of the current request.
+const csrfLocal = "csrf"
+
+// CSRFMiddleware protects server-rendered forms against cross-site request forgery. Browsers send
+// Basic credentials with every request, including ones forged by other sites, so every unsafe
+// request must echo the token of the CSRF cookie in the CSRFField form field.
+func CSRFMiddleware(path string) fiber.Handler {
+	return csrf.New(csrf.Config{
+		KeyLookup:         "form:" + CSRFField,
+		CookieName:        "csrf_",
+		CookiePath:        path,
+		CookieSameSite:    "Strict",
+		CookieHTTPOnly:    true,
+		CookieSessionOnly: true,
+		ContextKey:        csrfLocal,
+	})
+}
+
+// CSRFTokenFromCtx returns the token that forms rendered for this request must post back.
+func CSRFTokenFromCtx(c *fiber.Ctx) string {
+	token, _ := c.Locals(csrfLocal).(string)
+	return token
+}
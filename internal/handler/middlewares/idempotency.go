@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/pkg/idempotency"
+	"pack_optimizer/pkg/logpkg"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header that carries an idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses that were replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLen bounds the keys accepted from clients.
+	maxIdempotencyKeyLen = 255
+)
+
+// IdempotencyMiddleware stores the response of requests that carry an Idempotency-Key header for
+// ttl, scoped to the client, and replays it when the request is retried with the same key. A key
+// reused with a different method, URL or body is rejected with 422, and a retry that arrives
+// while the first request is still running with 409. Server errors and panics are not stored, so
+// the request can be retried. If the store fails, requests are processed without deduplication.
+func IdempotencyMiddleware(store idempotency.Store, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			return customerrrors.InvalidField(IdempotencyKeyHeader, "max", "must be at most 255 characters")
+		}
+
+		ctx := c.UserContext()
+		logger := logpkg.FromContext(ctx)
+		scopedKey := clientID(c) + ":" + key
+		hash := requestHash(c)
+
+		rec, started, err := store.Begin(ctx, scopedKey, hash, ttl)
+		if err != nil {
+			logger.Warn().Err(err).Msg("idempotency store failed")
+			return c.Next()
+		}
+		if !started {
+			switch {
+			case rec.RequestHash != hash:
+				return customerrrors.IdempotencyKeyReused()
+			case rec.Response == nil:
+				return customerrrors.IdempotencyKeyInUse()
+			}
+			c.Set(IdempotentReplayedHeader, "true")
+			c.Set(fiber.HeaderContentType, rec.Response.ContentType)
+			return c.Status(rec.Response.Status).Send(rec.Response.Body)
+		}
+
+		// The key is released unless a response is stored, including when the handler panics,
+		// so that retries are not rejected as in use until the record expires.
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Abort(ctx, scopedKey); err != nil {
+				logger.Warn().Err(err).Msg("idempotency store failed")
+			}
+		}()
+
+		// Errors are rendered here rather than by the app, so that the response can be stored.
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		resp := c.Response()
+		if resp.StatusCode() >= fiber.StatusInternalServerError {
+			return nil
+		}
+		completed = true
+		err = store.Complete(ctx, scopedKey, idempotency.Response{
+			Status:      resp.StatusCode(),
+			ContentType: string(resp.Header.ContentType()),
+			Body:        bytes.Clone(resp.Body()),
+		})
+		if err != nil {
+			logger.Warn().Err(err).Msg("idempotency store failed")
+		}
+		return nil
+	}
+}
+
+// requestHash fingerprints the parts of a request that must match when a key is reused.
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL())) // With the query string: ?dry_run=true is another request
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
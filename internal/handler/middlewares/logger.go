@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"pack_optimizer/internal/handler/customerrrors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// LoggerMiddleware stores a logger that already carries the request ID, method and path in the
+// request's user context, and writes one access log line per request through it.
+// It must run after RequestIDMiddleware.
+func LoggerMiddleware(c *fiber.Ctx) error {
+	start := time.Now()
+
+	reqID, _ := c.Locals("request_id").(string)
+	// Fiber strings point into reused request buffers, so they are cloned before the logger keeps them.
+	logger := log.With().
+		Str("request_id", reqID).
+		Str("method", strings.Clone(c.Method())).
+		Str("path", strings.Clone(c.Path())).
+		Logger()
+	c.SetUserContext(logger.WithContext(c.UserContext()))
+
+	err := c.Next()
+
+	event := logger.Info().
+		Int("status_code", statusCode(c, err)).
+		Dur("latency", time.Since(start)).
+		Str("ip", c.IP())
+	// Reading a streamed body would buffer the whole stream, and its size is unknown until it is sent.
+	if !c.Response().IsBodyStream() {
+		event = event.Int("bytes_sent", len(c.Response().Body()))
+	}
+	event.Msg("request handled")
+
+	return err
+}
+
+// statusCode returns the status the response will have once err reaches the central error handler,
+// which only runs after the middleware stack has returned.
+func statusCode(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	return customerrrors.ToAPIError(err).Status
+}
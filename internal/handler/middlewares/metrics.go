@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pack_optimizer_http_requests_total",
+		Help: "Number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pack_optimizer_http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// MetricsMiddleware records request counts and latencies per route and status code.
+// It must run before the recover middleware so that panics are counted as 500s.
+func MetricsMiddleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	// The route pattern, not the raw path, keeps label cardinality bounded.
+	// Fiber strings point into reused request buffers, so the method is cloned before it is kept as a label.
+	labels := prometheus.Labels{
+		"method": strings.Clone(c.Method()),
+		"route":  c.Route().Path,
+		"status": strconv.Itoa(statusCode(c, err)),
+	}
+	httpRequestsTotal.With(labels).Inc()
+	httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+
+	return err
+}
@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/pkg/logpkg"
+	"pack_optimizer/pkg/ratelimit"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Names of the two policies, used in bucket keys, headers and metrics.
+const (
+	requestsPolicy = "requests"
+	quotaPolicy    = "quota"
+)
+
+var rateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "pack_optimizer_rate_limited_total",
+	Help: "Number of requests rejected by a rate limit policy.",
+}, []string{"route", "policy"})
+
+// RateLimits configures the two buckets every client gets: Requests is charged one token per
+// request, Quota is charged the cost returned by the route's CostFunc.
+type RateLimits struct {
+	Requests ratelimit.Limit
+	Quota    ratelimit.Limit
+}
+
+// CostFunc returns how many quota tokens a request is charged, e.g. its order quantity.
+type CostFunc func(c *fiber.Ctx) float64
+
+// RateLimitMiddleware enforces the request and quota limits per client. Clients are identified
+// by their principal when AuthMiddleware authenticated them, and by IP address otherwise.
+// Tokens are only taken when both buckets allow the request. Each policy is reported in the
+// RateLimit-Policy and RateLimit headers; rejected requests get a 429 with Retry-After, and
+// requests costing more than a bucket can ever hold a 413. If the store fails, requests are
+// let through.
+func RateLimitMiddleware(store ratelimit.Store, limits RateLimits, cost CostFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client := clientID(c)
+		policies := []string{requestsPolicy, quotaPolicy}
+		charges := []ratelimit.Charge{
+			{Key: requestsPolicy + ":" + client, Cost: 1, Limit: limits.Requests},
+			{Key: quotaPolicy + ":" + client, Cost: max(cost(c), 1), Limit: limits.Quota},
+		}
+		for i, charge := range charges {
+			if charge.Cost > charge.Limit.Burst {
+				rateLimitedTotal.WithLabelValues(c.Route().Path, policies[i]).Inc()
+				return customerrrors.CostTooHigh(policies[i], charge.Cost, charge.Limit.Burst)
+			}
+		}
+
+		results, err := store.Take(c.UserContext(), charges...)
+		if err != nil {
+			logpkg.FromContext(c.UserContext()).Warn().Err(err).Msg("rate limit store failed")
+			return c.Next()
+		}
+
+		var rejected error
+		for i, res := range results {
+			limit := charges[i].Limit
+			window := seconds(time.Duration(limit.Burst / limit.Rate * float64(time.Second)))
+			c.Append("RateLimit-Policy", fmt.Sprintf("%q;q=%d;w=%d", policies[i], int64(limit.Burst), window))
+			c.Append("RateLimit", fmt.Sprintf("%q;r=%d;t=%d", policies[i], int64(res.Remaining), seconds(res.Reset)))
+
+			if !res.Allowed && rejected == nil {
+				rateLimitedTotal.WithLabelValues(c.Route().Path, policies[i]).Inc()
+				rejected = customerrrors.RateLimited(policies[i], seconds(res.RetryAfter))
+			}
+		}
+		if rejected != nil {
+			return rejected
+		}
+		return c.Next()
+	}
+}
+
+// seconds rounds a duration up to whole seconds, as required by Retry-After.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
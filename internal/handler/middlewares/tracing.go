@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TracingMiddleware reads W3C trace context (traceparent, tracestate, baggage) from the request
+// headers into the user context, so spans started by handlers join the caller's trace.
+func TracingMiddleware(c *fiber.Ctx) error {
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaderCarrier{c: c})
+	c.SetUserContext(ctx)
+	return c.Next()
+}
+
+// requestHeaderCarrier adapts Fiber request headers to propagation.TextMapCarrier.
+type requestHeaderCarrier struct {
+	c *fiber.Ctx
+}
+
+var _ propagation.TextMapCarrier = requestHeaderCarrier{}
+
+func (r requestHeaderCarrier) Get(key string) string {
+	return r.c.Get(key)
+}
+
+func (r requestHeaderCarrier) Set(key, value string) {
+	r.c.Request().Header.Set(key, value)
+}
+
+func (r requestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, r.c.Request().Header.Len())
+	for key := range r.c.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,39 @@
+package packhandler
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The cost functions below weight requests for the rate limiter's quota by the number of items the
+// solver has to search through. Malformed requests cost the minimum; the handler rejects them anyway.
+
+// QuantityCost charges the order quantity of calculate and pareto requests.
+func QuantityCost(c *fiber.Ctx) float64 {
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return 0
+	}
+	return float64(req.Quantity)
+}
+
+// SweepCost charges the upper bound of a sweep, since the table is built up to it.
+func SweepCost(c *fiber.Ctx) float64 {
+	return float64(c.QueryInt("to"))
+}
+
+// ReverseLookupCost charges the number of items in the looked-up combination.
+func ReverseLookupCost(c *fiber.Ctx) float64 {
+	var req ReverseLookupReq
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return 0
+	}
+	total := 0.0
+	for size, count := range req.Packs {
+		total += float64(size) * float64(count)
+	}
+	return total
+}
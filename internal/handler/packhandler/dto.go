@@ -2,5 +2,31 @@
 package packhandler
 
 type CalculatePacksReq struct {
-	Quantity int `json:"quantity" validate:"required,min=1,max=99999999"` // Quantity must be between 1 and 99,999,999
+	Quantity  int           `json:"quantity" validate:"required,min=1,max=99999999"` // Quantity must be between 1 and 99,999,999
+	ExactOnly bool          `json:"exact_only"`                                      // Refuse solutions that ship more than ordered
+	Tolerance *ToleranceReq `json:"tolerance"`                                       // Optional window around the quantity
+}
+
+// ToleranceReq allows a plan to ship up to Under items fewer or Over items more than ordered.
+// With Unit "percent" both values are percentages of the ordered quantity.
+type ToleranceReq struct {
+	Under float64 `json:"under" validate:"min=0,max=99999999"`
+	Over  float64 `json:"over" validate:"min=0,max=99999999"`
+	Unit  string  `json:"unit" validate:"omitempty,oneof=absolute percent"`
+}
+
+type ParetoFrontReq struct {
+	Quantity  int `json:"quantity" validate:"required,min=1,max=99999999"` // Quantity must be between 1 and 99,999,999
+	MaxPoints int `json:"max_points" validate:"omitempty,min=1,max=100"`   // Defaults to DefaultParetoPoints
+}
+
+type SweepReq struct {
+	From   int    `query:"from" validate:"required,min=1,max=1000000"`             // First quantity of the sweep
+	To     int    `query:"to" validate:"required,min=1,max=1000000,gtefield=From"` // Last quantity of the sweep, inclusive
+	Step   int    `query:"step" validate:"omitempty,min=1"`                        // Defaults to 1
+	Format string `query:"format" validate:"omitempty,oneof=ndjson csv"`           // Defaults to ndjson
+}
+
+type ReverseLookupReq struct {
+	Packs map[int]int `json:"packs" validate:"required,min=1,dive,keys,min=1,endkeys,min=0"` // Pack count keyed by pack size
 }
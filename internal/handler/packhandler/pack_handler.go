@@ -2,46 +2,117 @@
 package packhandler
 
 import (
-	"errors"
-	"pack_optimizer/internal/domain"
+	"context"
 	"pack_optimizer/internal/handler/customerrrors"
 	"pack_optimizer/internal/usecase/packusecase"
 	"pack_optimizer/pkg/validator"
 
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultParetoPoints is the number of points returned by ParetoFront when the request does not set max_points.
+const DefaultParetoPoints = 20
+
+var tracer = otel.Tracer("pack_optimizer/internal/handler/packhandler")
+
+// PackService is the pack use case as seen by PackHandler. It is implemented by
+// packusecase.PackUseCase and by packusecase.AdmittedPackUseCase, which adds admission control.
+type PackService interface {
+	CalculatePacksWithOptions(ctx context.Context, orderQty int, opts packusecase.CalculateOptions) (packusecase.CalculatePacksOutput, error)
+	ParetoFront(ctx context.Context, orderQty, maxPoints int) (packusecase.ParetoOutput, error)
+	ReverseLookup(ctx context.Context, combination map[int]int) (packusecase.ReverseLookupOutput, error)
+	NewSweeper(ctx context.Context, from, to, step int) (*packusecase.Sweeper, error)
+}
+
 type PackHandler struct {
-	packUseCase *packusecase.PackUseCase
+	packUseCase PackService
 }
 
-func NewPackHandler(packUseCase *packusecase.PackUseCase) *PackHandler {
+func NewPackHandler(packUseCase PackService) *PackHandler {
 	return &PackHandler{packUseCase: packUseCase}
 }
 
 func (h *PackHandler) CalculatePacks(c *fiber.Ctx) error {
+	reqID, _ := c.Locals("request_id").(string)
+	ctx, span := tracer.Start(c.UserContext(), "PackHandler.CalculatePacks",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("request_id", reqID)),
+	)
+	defer span.End()
+
 	var req CalculatePacksReq
 
 	// Parse and validate JSON body
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request",
-		})
+		return customerrrors.InvalidRequest(err)
 	}
 
 	// Validate the input
 	if err := validator.Validate.Struct(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	output, err := h.packUseCase.CalculatePacks(c.Context(), req.Quantity)
-	if err != nil {
-		if errors.Is(err, domain.ErrNoPacksAvailable) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	opts := packusecase.CalculateOptions{ExactOnly: req.ExactOnly}
+	if req.Tolerance != nil {
+		opts.Tolerance = &packusecase.Tolerance{
+			Under: req.Tolerance.Under,
+			Over:  req.Tolerance.Over,
+			Unit:  packusecase.ToleranceUnit(req.Tolerance.Unit),
 		}
+	}
+	output, err := h.packUseCase.CalculatePacksWithOptions(ctx, req.Quantity, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(output)
+}
+
+func (h *PackHandler) ParetoFront(c *fiber.Ctx) error {
+	var req ParetoFrontReq
 
-		// For all other errors, we return a 500.
-		return customerrrors.ErrUnexpected
+	// Parse and validate JSON body
+	if err := c.BodyParser(&req); err != nil {
+		return customerrrors.InvalidRequest(err)
+	}
+
+	// Validate the input
+	if err := validator.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	if req.MaxPoints == 0 {
+		req.MaxPoints = DefaultParetoPoints
+	}
+
+	output, err := h.packUseCase.ParetoFront(c.UserContext(), req.Quantity, req.MaxPoints)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(output)
+}
+
+func (h *PackHandler) ReverseLookup(c *fiber.Ctx) error {
+	var req ReverseLookupReq
+
+	// Parse and validate JSON body
+	if err := c.BodyParser(&req); err != nil {
+		return customerrrors.InvalidRequest(err)
+	}
+
+	// Validate the input
+	if err := validator.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	output, err := h.packUseCase.ReverseLookup(c.UserContext(), req.Packs)
+	if err != nil {
+		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(output)
 }
@@ -0,0 +1,156 @@
+package packhandler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/usecase/packusecase"
+	"pack_optimizer/pkg/logpkg"
+	"pack_optimizer/pkg/validator"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaxSweepRows caps the number of quantities a single sweep may produce.
+const MaxSweepRows = 100000
+
+// sweepFlushEvery is how many rows are buffered before they are flushed to the client.
+const sweepFlushEvery = 256
+
+// Sweep streams the optimal packs for every quantity from `from` to `to` in steps of `step`,
+// as NDJSON (one JSON object per line) or CSV with one column per pack size.
+func (h *PackHandler) Sweep(c *fiber.Ctx) error {
+	var req SweepReq
+
+	if err := c.QueryParser(&req); err != nil {
+		return customerrrors.InvalidRequest(err)
+	}
+
+	// Validate the input
+	if err := validator.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	if req.Step == 0 {
+		req.Step = 1
+	}
+	if (req.To-req.From)/req.Step+1 > MaxSweepRows {
+		return customerrrors.InvalidField("to", "max_rows",
+			"sweep must not produce more than "+strconv.Itoa(MaxSweepRows)+" rows")
+	}
+
+	sweeper, err := h.packUseCase.NewSweeper(c.UserContext(), req.From, req.To, req.Step)
+	if err != nil {
+		return err
+	}
+
+	// The body is streamed after the handler returns, on the request's context.
+	ctx := c.UserContext()
+	write := writeSweepNDJSON
+	if req.Format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="sweep.csv"`)
+		write = writeSweepCSV
+	} else {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	}
+
+	c.Context().SetBodyStreamWriter(func(bw *bufio.Writer) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if err := write(ctx, &sweepWriter{w: bw, cancel: cancel}, sweeper); err != nil {
+			logpkg.FromContext(ctx).Warn().Err(err).Msg("sweep stream aborted")
+		}
+	})
+	return nil
+}
+
+// sweepWriter cancels the sweep on the first failed write or flush. The request's context is not
+// cancelled when the client disconnects, so a failed write is the first sign that nobody is reading.
+type sweepWriter struct {
+	w      *bufio.Writer
+	cancel context.CancelFunc
+}
+
+func (s *sweepWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if err != nil {
+		s.cancel()
+	}
+	return n, err
+}
+
+func (s *sweepWriter) Flush() error {
+	err := s.w.Flush()
+	if err != nil {
+		s.cancel()
+	}
+	return err
+}
+
+func writeSweepNDJSON(ctx context.Context, w *sweepWriter, sweeper *packusecase.Sweeper) error {
+	enc := json.NewEncoder(w)
+	rows := 0
+	err := sweeper.Run(ctx, func(row packusecase.SweepRow) error {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+		if rows++; rows%sweepFlushEvery == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeSweepCSV(ctx context.Context, w *sweepWriter, sweeper *packusecase.Sweeper) error {
+	sizes := sweeper.PackSizes()
+	header := []string{"quantity", "total_items", "remaining_items", "total_packs"}
+	for _, size := range sizes {
+		header = append(header, "pack_"+strconv.Itoa(size))
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	rows := 0
+	record := make([]string, len(header))
+	err := sweeper.Run(ctx, func(row packusecase.SweepRow) error {
+		counts := make(map[int]int, len(row.Packs))
+		for _, p := range row.Packs {
+			counts[p.Size] = p.Count
+		}
+
+		record[0] = strconv.Itoa(row.Quantity)
+		record[1] = strconv.Itoa(row.TotalItems)
+		record[2] = strconv.Itoa(row.RemainingItems)
+		record[3] = strconv.Itoa(row.TotalPacks)
+		for i, size := range sizes {
+			record[4+i] = strconv.Itoa(counts[size])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		if rows++; rows%sweepFlushEvery == 0 {
+			cw.Flush()
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
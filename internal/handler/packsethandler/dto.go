@@ -0,0 +1,21 @@
+package packsethandler
+
+import "pack_optimizer/internal/usecase/packsetusecase"
+
+// ExportReq selects the document format; without it the Accept header decides and JSON is the default.
+type ExportReq struct {
+	Format string `query:"format" validate:"omitempty,oneof=json yaml"`
+}
+
+// ImportReq holds the query parameters of an import. The document itself is the request body.
+type ImportReq struct {
+	DryRun bool   `query:"dry_run"`                                       // Only report what the import would change
+	Mode   string `query:"mode" validate:"omitempty,oneof=replace merge"` // replace (default) removes sizes missing from the document
+}
+
+// ImportResp reports the changes an import made, or would make in a dry run.
+type ImportResp struct {
+	DryRun bool                `json:"dry_run"`
+	Mode   string              `json:"mode"`
+	Diff   packsetusecase.Diff `json:"diff"`
+}
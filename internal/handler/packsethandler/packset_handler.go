@@ -0,0 +1,111 @@
+// Package packsethandler provides HTTP handlers for importing and exporting pack sets.
+package packsethandler
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/usecase/packsetusecase"
+	"pack_optimizer/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Import modes.
+const (
+	ModeReplace = "replace"
+	ModeMerge   = "merge"
+)
+
+// contentTypes maps document formats to the media type they are served with.
+var contentTypes = map[string]string{
+	packsetusecase.FormatJSON: fiber.MIMEApplicationJSONCharsetUTF8,
+	packsetusecase.FormatYAML: "application/yaml; charset=utf-8",
+}
+
+// formatsByMediaType maps the media types accepted for imports to document formats.
+var formatsByMediaType = map[string]string{
+	fiber.MIMEApplicationJSON: packsetusecase.FormatJSON,
+	"application/yaml":        packsetusecase.FormatYAML,
+	"application/x-yaml":      packsetusecase.FormatYAML,
+	"text/yaml":               packsetusecase.FormatYAML,
+}
+
+// PackSetService is the pack set use case as seen by PackSetHandler.
+type PackSetService interface {
+	Export(ctx context.Context, source string) (packsetusecase.Document, error)
+	Import(ctx context.Context, doc packsetusecase.Document, opts packsetusecase.ImportOptions) (packsetusecase.Diff, error)
+}
+
+type PackSetHandler struct {
+	packSetUseCase PackSetService
+	source         string // Environment name recorded in exported documents
+}
+
+func NewPackSetHandler(packSetUseCase PackSetService, source string) *PackSetHandler {
+	return &PackSetHandler{packSetUseCase: packSetUseCase, source: source}
+}
+
+// Export returns the stored pack set as a versioned JSON or YAML document.
+func (h *PackSetHandler) Export(c *fiber.Ctx) error {
+	var req ExportReq
+	if err := c.QueryParser(&req); err != nil {
+		return customerrrors.InvalidRequest(err)
+	}
+	if err := validator.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	format := req.Format
+	if format == "" {
+		format = packsetusecase.FormatJSON
+		if c.Accepts(fiber.MIMEApplicationJSON, "application/yaml") == "application/yaml" {
+			format = packsetusecase.FormatYAML
+		}
+	}
+
+	doc, err := h.packSetUseCase.Export(c.UserContext(), h.source)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := packsetusecase.EncodeDocument(&body, doc, format); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, contentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="packset.`+format+`"`)
+	return c.Status(fiber.StatusOK).Send(body.Bytes())
+}
+
+// Import replaces or extends the stored pack set with the document in the body,
+// whose format is taken from the Content-Type header.
+func (h *PackSetHandler) Import(c *fiber.Ctx) error {
+	var req ImportReq
+	if err := c.QueryParser(&req); err != nil {
+		return customerrrors.InvalidRequest(err)
+	}
+	if err := validator.Validate.Struct(req); err != nil {
+		return err
+	}
+	if req.Mode == "" {
+		req.Mode = ModeReplace
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	format, ok := formatsByMediaType[mediaType]
+	if !ok {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "the document must be sent as application/json or application/yaml")
+	}
+	doc, err := packsetusecase.DecodeDocument(c.Body(), format)
+	if err != nil {
+		return err
+	}
+
+	opts := packsetusecase.ImportOptions{Merge: req.Mode == ModeMerge, DryRun: req.DryRun}
+	diff, err := h.packSetUseCase.Import(c.UserContext(), doc, opts)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(ImportResp{DryRun: req.DryRun, Mode: req.Mode, Diff: diff})
+}
@@ -2,21 +2,73 @@
 package handler
 
 import (
+	"pack_optimizer/configs"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/adminhandler"
+	"pack_optimizer/internal/handler/healthhandler"
+	"pack_optimizer/internal/handler/historyhandler"
+	"pack_optimizer/internal/handler/middlewares"
 	"pack_optimizer/internal/handler/packhandler"
+	"pack_optimizer/internal/handler/packsethandler"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // SetupRoutes registers all application routes.
-func SetupRoutes(app *fiber.App, packHandler *packhandler.PackHandler) {
+func SetupRoutes(
+	app *fiber.App,
+	packHandler *packhandler.PackHandler,
+	packSetHandler *packsethandler.PackSetHandler,
+	adminHandler *adminhandler.AdminHandler,
+	historyHandler *historyhandler.HistoryHandler,
+	healthHandler *healthhandler.HealthHandler,
+	apiKeys middlewares.Authenticator,
+	tokens middlewares.Authenticator,
+	auth configs.Auth,
+	limit func(cost middlewares.CostFunc) fiber.Handler,
+	idempotent fiber.Handler,
+) {
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.Render("index", fiber.Map{})
 	})
 
+	// pages, signed in with an API key as the Basic auth password
+	browserAuth := middlewares.BasicAuthMiddleware(apiKeys, "Pack Optimizer")
+	// admin
+	admin := app.Group("/admin", browserAuth, middlewares.RequireRole(false, domain.RolePackAdmin), middlewares.CSRFMiddleware("/admin"))
+	admin.Get("/", func(c *fiber.Ctx) error {
+		return c.Redirect("/admin/packs")
+	})
+	admin.Get("/packs", adminHandler.ListPacks)
+	admin.Post("/packs", adminHandler.AddPack)
+	admin.Get("/packs/:size/edit", adminHandler.EditPack)
+	admin.Post("/packs/:size/edit", adminHandler.UpdatePack)
+	admin.Post("/packs/:size/delete", adminHandler.RemovePack)
+	// calculation history; a single calculation may be opened by anyone who can calculate, so links can be shared
+	history := app.Group("/history", browserAuth)
+	history.Get("/", middlewares.RequireRole(false, domain.RoleAuditor, domain.RolePackAdmin), historyHandler.List)
+	history.Get("/:id", middlewares.RequireRole(false, domain.RoleCalculator, domain.RolePackAdmin, domain.RoleAuditor), historyHandler.Show)
+
+	// health
+	app.Get("/healthz", healthHandler.Liveness)
+	app.Get("/readyz", healthHandler.Readiness)
+
+	// metrics
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
 	// api
-	api := app.Group("/api")
+	api := app.Group("/api", middlewares.AuthMiddleware(apiKeys, tokens))
 	// api v1
 	apiV1 := api.Group("/v1")
 	// packs
-	apiV1.Post("/packs/calculate", packHandler.CalculatePacks)
+	calculate := middlewares.RequireRole(auth.AnonymousCalculation, domain.RoleCalculator, domain.RolePackAdmin)
+	apiV1.Post("/packs/calculate", calculate, limit(packhandler.QuantityCost), idempotent, packHandler.CalculatePacks)
+	apiV1.Post("/packs/pareto", calculate, limit(packhandler.QuantityCost), idempotent, packHandler.ParetoFront)
+	apiV1.Get("/packs/sweep", calculate, limit(packhandler.SweepCost), packHandler.Sweep)
+	apiV1.Post("/packs/reverse", calculate, limit(packhandler.ReverseLookupCost), idempotent, packHandler.ReverseLookup)
+	// pack sets
+	apiV1.Get("/packsets/export", middlewares.RequireRole(false, domain.RolePackAdmin, domain.RoleAuditor), packSetHandler.Export)
+	apiV1.Post("/packsets/import", middlewares.RequireRole(false, domain.RolePackAdmin), idempotent, packSetHandler.Import)
 }
@@ -2,53 +2,93 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"os/signal"
 	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/adminhandler"
 	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/healthhandler"
+	"pack_optimizer/internal/handler/historyhandler"
 	"pack_optimizer/internal/handler/middlewares"
 	"pack_optimizer/internal/handler/packhandler"
+	"pack_optimizer/internal/handler/packsethandler"
 	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+	"pack_optimizer/internal/usecase/historyusecase"
+	"pack_optimizer/internal/usecase/packsetusecase"
 	"pack_optimizer/internal/usecase/packusecase"
+	"pack_optimizer/pkg/admission"
+	"pack_optimizer/pkg/idempotency"
+	"pack_optimizer/pkg/ratelimit"
 	"pack_optimizer/templates"
+	"runtime"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/template/html/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gorm.io/gorm"
 )
 
 // Server encapsulates the Fiber app and its configuration.
 // It is a single, cohesive component responsible for the HTTP server.
 type Server struct {
-	App *fiber.App
-	DB  *gorm.DB
+	App    *fiber.App
+	DB     *gorm.DB
+	Config configs.Config
+	Tokens middlewares.Authenticator // Validates SSO bearer tokens; nil when no issuer is configured
+
+	shuttingDown atomic.Bool // set once a shutdown signal is received, so readiness probes fail
+
+	background     context.Context // Cancelled once the server has stopped, which ends the periodic jobs
+	stopBackground context.CancelFunc
 }
 
+// bucketCleanupInterval is how often idle rate limit buckets are deleted from the database.
+const bucketCleanupInterval = 10 * time.Minute
+
+// historyPruneInterval is how often calculations past the history retention are deleted.
+const historyPruneInterval = time.Hour
+
 // NewServer initializes and returns a new Server instance.
 // This function acts as a factory, building the Fiber app with default
 // settings and applying any optional configurations.
-func NewServer(db *gorm.DB) *Server {
+func NewServer(db *gorm.DB, cfg configs.Config, tokens middlewares.Authenticator) *Server {
 	// A new, corrected FileSystem wrapper for go:embed
 	engine := html.NewFileSystem(http.FS(templates.FS), ".html")
 
 	app := fiber.New(fiber.Config{
 		ErrorHandler: customerrrors.ErrorHandler,
 		Views:        engine,
+		ReadTimeout:  cfg.App.ReadTimeout,
+		WriteTimeout: cfg.App.WriteTimeout,
+		IdleTimeout:  cfg.App.IdleTimeout,
 	})
 
-	app.Use(recover.New())                   // Recover from panics
-	app.Use(logger.New())                    // Log requests to the console
+	app.Use(middlewares.MetricsMiddleware)   // Record request counts and latencies for Prometheus
 	app.Use(middlewares.RequestIDMiddleware) // Add a unique request ID to each request for log tracing
+	app.Use(middlewares.LoggerMiddleware)    // Attach a request-scoped logger and write access logs
+	app.Use(recover.New())                   // Recover from panics
+	app.Use(middlewares.TracingMiddleware)   // Continue traces propagated through W3C traceparent headers
 
+	background, stopBackground := context.WithCancel(context.Background())
 	return &Server{
-		App: app,
-		DB:  db,
+		App:            app,
+		DB:             db,
+		Config:         cfg,
+		Tokens:         tokens,
+		background:     background,
+		stopBackground: stopBackground,
 	}
 }
 
@@ -73,12 +113,20 @@ func (s *Server) Run(appConfig configs.App) {
 	<-shutdownChan
 
 	log.Info().Msg("Received shutdown signal, initiating graceful shutdown...")
+	s.shuttingDown.Store(true)
+
+	// Keep serving while load balancers notice the failing readiness probe.
+	if appConfig.ShutdownDrain > 0 {
+		log.Info().Dur("drain", appConfig.ShutdownDrain).Msg("Waiting for in-flight traffic to drain")
+		time.Sleep(appConfig.ShutdownDrain)
+	}
 
 	// Use Fiber's built-in Shutdown() method.
 	if err := s.App.Shutdown(); err != nil {
 		log.Error().Err(err).Msg("Failed to gracefully shut down Fiber server")
 	}
 	log.Info().Msg("Fiber server has been gracefully shut down.")
+	s.stopBackground()
 
 	// Gracefully close the database connection.
 	sqlDB, err := s.DB.DB()
@@ -95,16 +143,168 @@ func (s *Server) Run(appConfig configs.App) {
 }
 
 func (s *Server) setupRoutes() {
-	packHandler := packhandler.NewPackHandler(packusecase.NewPackUseCase(sqlrepo.NewPackRepo(s.DB)))
+	packRepo := sqlrepo.NewPackRepo(s.DB)
+	var packService historyusecase.PackService = s.admittedPackUseCase(packusecase.NewPackUseCase(packRepo, packusecase.WithLimits(packusecase.Limits{
+		MaxQuantity: s.Config.Solver.MaxQuantity,
+		MaxStates:   s.Config.Solver.MaxStates,
+		MaxTable:    s.Config.Solver.MaxTable,
+	})))
+	historyUseCase := historyusecase.NewHistoryUseCase(sqlrepo.NewCalculationRepo(s.DB))
+	if s.Config.History.Enabled {
+		packService = historyusecase.NewRecordingPackUseCase(packService, historyUseCase)
+	}
+	if retention := s.Config.History.Retention; retention > 0 {
+		s.every(historyPruneInterval, "calculation history prune", func(ctx context.Context) error {
+			deleted, err := historyUseCase.Prune(ctx, retention)
+			if deleted > 0 {
+				log.Info().Int64("deleted", deleted).Msg("Pruned calculation history")
+			}
+			return err
+		})
+	}
+	packHandler := packhandler.NewPackHandler(packService)
+	historyHandler := historyhandler.NewHistoryHandler(historyUseCase)
+	packSetUseCase := packsetusecase.NewPackSetUseCase(packRepo, packsetusecase.WithMaxPackSize(s.Config.Solver.MaxPackSize))
+	packSetHandler := packsethandler.NewPackSetHandler(packSetUseCase, s.Config.Env)
+	adminHandler := adminhandler.NewAdminHandler(packSetUseCase)
+	healthHandler := healthhandler.NewHealthHandler(s.shuttingDown.Load, s.readinessChecks(packRepo)...)
+	apiKeyUseCase := apikeyusecase.NewAPIKeyUseCase(sqlrepo.NewAPIKeyRepo(s.DB))
 
 	s.App.Get("/", func(c *fiber.Ctx) error {
 		return c.Render("index", fiber.Map{})
 	})
 
+	// pages, signed in with an API key as the Basic auth password
+	browserAuth := middlewares.BasicAuthMiddleware(apiKeyUseCase, "Pack Optimizer")
+	// admin
+	admin := s.App.Group("/admin", browserAuth, middlewares.RequireRole(false, domain.RolePackAdmin), middlewares.CSRFMiddleware("/admin"))
+	admin.Get("/", func(c *fiber.Ctx) error {
+		return c.Redirect("/admin/packs")
+	})
+	admin.Get("/packs", adminHandler.ListPacks)
+	admin.Post("/packs", adminHandler.AddPack)
+	admin.Get("/packs/:size/edit", adminHandler.EditPack)
+	admin.Post("/packs/:size/edit", adminHandler.UpdatePack)
+	admin.Post("/packs/:size/delete", adminHandler.RemovePack)
+	// calculation history; a single calculation may be opened by anyone who can calculate, so links can be shared
+	history := s.App.Group("/history", browserAuth)
+	history.Get("/", middlewares.RequireRole(false, domain.RoleAuditor, domain.RolePackAdmin), historyHandler.List)
+	history.Get("/:id", middlewares.RequireRole(false, domain.RoleCalculator, domain.RolePackAdmin, domain.RoleAuditor), historyHandler.Show)
+
+	// health
+	s.App.Get("/healthz", healthHandler.Liveness)
+	s.App.Get("/readyz", healthHandler.Readiness)
+
+	// metrics
+	s.App.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
 	// api
-	api := s.App.Group("/api")
+	api := s.App.Group("/api", middlewares.AuthMiddleware(apiKeyUseCase, s.Tokens))
 	// api v1
 	apiV1 := api.Group("/v1")
 	// packs
-	apiV1.Post("/packs/calculate", packHandler.CalculatePacks)
+	calculate := middlewares.RequireRole(s.Config.Auth.AnonymousCalculation, domain.RoleCalculator, domain.RolePackAdmin)
+	limit := s.rateLimiter()
+	idempotent := s.idempotency()
+	apiV1.Post("/packs/calculate", calculate, limit(packhandler.QuantityCost), idempotent, packHandler.CalculatePacks)
+	apiV1.Post("/packs/pareto", calculate, limit(packhandler.QuantityCost), idempotent, packHandler.ParetoFront)
+	apiV1.Get("/packs/sweep", calculate, limit(packhandler.SweepCost), packHandler.Sweep)
+	apiV1.Post("/packs/reverse", calculate, limit(packhandler.ReverseLookupCost), idempotent, packHandler.ReverseLookup)
+	// pack sets
+	apiV1.Get("/packsets/export", middlewares.RequireRole(false, domain.RolePackAdmin, domain.RoleAuditor), packSetHandler.Export)
+	apiV1.Post("/packsets/import", middlewares.RequireRole(false, domain.RolePackAdmin), idempotent, packSetHandler.Import)
+}
+
+// admittedPackUseCase puts the admission controller configured in Config.Admission in front of the solver.
+func (s *Server) admittedPackUseCase(uc *packusecase.PackUseCase) *packusecase.AdmittedPackUseCase {
+	cfg := s.Config.Admission
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent == 0 {
+		maxConcurrent = runtime.NumCPU()
+	}
+	return packusecase.NewAdmittedPackUseCase(uc, admission.NewController(maxConcurrent, cfg.QueueDepth, cfg.MaxWait))
+}
+
+// rateLimiter returns a factory for the rate limit middleware of each calculation route.
+// When rate limiting is disabled, the middleware does nothing.
+func (s *Server) rateLimiter() func(cost middlewares.CostFunc) fiber.Handler {
+	cfg := s.Config.RateLimit
+	if !cfg.Enabled {
+		return func(middlewares.CostFunc) fiber.Handler {
+			return func(c *fiber.Ctx) error { return c.Next() }
+		}
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.Store == "database" {
+		dbStore := sqlrepo.NewRateLimitStore(s.DB)
+		s.every(bucketCleanupInterval, "rate limit bucket cleanup", dbStore.DeleteIdle)
+		store = dbStore
+	}
+	limits := middlewares.RateLimits{
+		Requests: ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst},
+		Quota:    ratelimit.Limit{Rate: cfg.QuotaRate, Burst: cfg.QuotaBurst},
+	}
+	return func(cost middlewares.CostFunc) fiber.Handler {
+		return middlewares.RateLimitMiddleware(store, limits, cost)
+	}
+}
+
+// every runs job in the background every interval until the server has stopped, logging its failures.
+func (s *Server) every(interval time.Duration, name string, job func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.background.Done():
+				return
+			case <-ticker.C:
+				if err := job(s.background); err != nil {
+					log.Warn().Err(err).Str("job", name).Msg("Background job failed")
+				}
+			}
+		}
+	}()
+}
+
+// idempotency returns the middleware that replays responses of requests retried with the same Idempotency-Key.
+// It runs after the rate limiter, so rejected requests are never stored.
+func (s *Server) idempotency() fiber.Handler {
+	cfg := s.Config.Idempotency
+	var store idempotency.Store = idempotency.NewMemoryStore()
+	if cfg.Store == "database" {
+		store = sqlrepo.NewIdempotencyStore(s.DB)
+	}
+	return middlewares.IdempotencyMiddleware(store, cfg.TTL)
+}
+
+// readinessChecks returns the dependency checks behind the /readyz endpoint.
+func (s *Server) readinessChecks(packRepo domain.PackRepository) []healthhandler.Check {
+	return []healthhandler.Check{
+		{
+			Name: "database",
+			Run: func(ctx context.Context) error {
+				sqlDB, err := s.DB.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			},
+			Details: func() map[string]any { return db.PoolStats(s.DB) },
+		},
+		{
+			Name: "migrations",
+			Run: func(ctx context.Context) error {
+				return db.CheckVersion(ctx, s.Config.DB.Driver, s.DB)
+			},
+		},
+		{
+			Name: "packs",
+			Run: func(ctx context.Context) error {
+				_, err := packRepo.GetAllPacks(ctx)
+				return err
+			},
+		},
+	}
 }
@@ -0,0 +1,50 @@
+// Package memrepo provides repositories that keep their data in memory, for tools that run
+// without a database.
+package memrepo
+
+import (
+	"context"
+	"pack_optimizer/internal/domain"
+	"slices"
+	"sync"
+)
+
+// PackRepo is a repository that keeps pack sizes in memory. It is safe for concurrent use.
+type PackRepo struct {
+	mu    sync.RWMutex
+	sizes []int // sizes is kept sorted ascending and free of duplicates.
+}
+
+// NewPackRepo creates a PackRepo holding sizes. Duplicates are ignored.
+func NewPackRepo(sizes []int) domain.PackSetRepository {
+	r := &PackRepo{}
+	_ = r.UpdatePacks(context.Background(), sizes, nil)
+	return r
+}
+
+// GetAllPacks returns the packs ordered by size in ascending order.
+func (r *PackRepo) GetAllPacks(_ context.Context) ([]domain.Pack, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.sizes) == 0 {
+		return nil, domain.ErrNoPacksAvailable
+	}
+	packs := make([]domain.Pack, len(r.sizes))
+	for i, size := range r.sizes {
+		packs[i] = domain.Pack{Size: size}
+	}
+	return packs, nil
+}
+
+// UpdatePacks adds and removes pack sizes. Existing sizes are not added twice.
+func (r *PackRepo) UpdatePacks(_ context.Context, add, remove []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sizes = slices.DeleteFunc(r.sizes, func(size int) bool { return slices.Contains(remove, size) })
+	r.sizes = append(r.sizes, add...)
+	slices.Sort(r.sizes)
+	r.sizes = slices.Compact(r.sizes)
+	return nil
+}
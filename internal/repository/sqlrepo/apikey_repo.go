@@ -0,0 +1,61 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepo is a repository that stores hashed API keys in the database.
+type APIKeyRepo struct {
+	db *gorm.DB // db is the GORM database connection.
+}
+
+// NewAPIKeyRepo creates a new instance of APIKeyRepo.
+func NewAPIKeyRepo(db *gorm.DB) domain.APIKeyRepository {
+	return &APIKeyRepo{db: db}
+}
+
+// CreateAPIKey inserts a new API key and sets its ID.
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash looks up an API key, revoked or not, by the hash of its value.
+func (r *APIKeyRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).Take(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("failed to retrieve api key: %w", err)
+	}
+	return key, nil
+}
+
+// RevokeAPIKey marks an API key as revoked. Revoking an already revoked key keeps the first timestamp.
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, id uint, revokedAt time.Time) error {
+	var key domain.APIKey
+	err := r.db.WithContext(ctx).Take(&key, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve api key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Model(&key).Update("revoked_at", revokedAt).Error; err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
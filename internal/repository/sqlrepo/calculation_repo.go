@@ -0,0 +1,88 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalculationRepo is a repository that stores the calculation history in the database.
+type CalculationRepo struct {
+	db *gorm.DB // db is the GORM database connection.
+}
+
+// NewCalculationRepo creates a new instance of CalculationRepo.
+func NewCalculationRepo(db *gorm.DB) domain.CalculationRepository {
+	return &CalculationRepo{db: db}
+}
+
+// CreateCalculation inserts a recorded calculation.
+func (r *CalculationRepo) CreateCalculation(ctx context.Context, calculation *domain.Calculation) error {
+	if err := r.db.WithContext(ctx).Create(calculation).Error; err != nil {
+		return fmt.Errorf("failed to record calculation: %w", err)
+	}
+	return nil
+}
+
+// GetCalculation looks up a recorded calculation by its ID.
+func (r *CalculationRepo) GetCalculation(ctx context.Context, id string) (domain.Calculation, error) {
+	var calculation domain.Calculation
+	err := r.db.WithContext(ctx).Where("id = ?", id).Take(&calculation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Calculation{}, domain.ErrCalculationNotFound
+	}
+	if err != nil {
+		return domain.Calculation{}, fmt.Errorf("failed to retrieve calculation: %w", err)
+	}
+	return calculation, nil
+}
+
+// ListCalculations returns the calculations matching filter, most recent first.
+func (r *CalculationRepo) ListCalculations(ctx context.Context, filter domain.CalculationFilter) ([]domain.Calculation, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Calculation{})
+	if filter.MinQuantity > 0 {
+		query = query.Where("quantity >= ?", filter.MinQuantity)
+	}
+	if filter.MaxQuantity > 0 {
+		query = query.Where("quantity <= ?", filter.MaxQuantity)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	switch filter.Outcome {
+	case domain.OutcomeExact:
+		query = query.Where("difference = 0")
+	case domain.OutcomeOver:
+		query = query.Where("difference > 0")
+	case domain.OutcomeUnder:
+		query = query.Where("difference < 0")
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var calculations []domain.Calculation
+	if err := query.Order("created_at DESC").Order("id").Find(&calculations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list calculations: %w", err)
+	}
+	return calculations, nil
+}
+
+// DeleteCalculationsOlderThan deletes the calculations recorded before cutoff and returns how many there were.
+func (r *CalculationRepo) DeleteCalculationsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&domain.Calculation{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old calculations: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
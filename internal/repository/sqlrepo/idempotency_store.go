@@ -0,0 +1,98 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/pkg/idempotency"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyCleanupEvery is the number of started requests between deletions of expired records.
+const idempotencyCleanupEvery = 1000
+
+// idempotencyRecord is one row of the idempotency_records table. StatusCode is 0 while the
+// request that reserved the key is still being processed.
+type idempotencyRecord struct {
+	RecordKey   string `gorm:"primaryKey"`
+	RequestHash string `gorm:"not null"`
+	StatusCode  int    `gorm:"not null"`
+	ContentType string `gorm:"not null"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"not null"`
+}
+
+func (idempotencyRecord) TableName() string {
+	return "idempotency_records"
+}
+
+// IdempotencyStore keeps idempotency records in the database, so retries are recognised by every server instance.
+type IdempotencyStore struct {
+	db      *gorm.DB // db is the GORM database connection.
+	started atomic.Int64
+}
+
+// NewIdempotencyStore creates a new instance of IdempotencyStore.
+func NewIdempotencyStore(db *gorm.DB) idempotency.Store {
+	return &IdempotencyStore{db: db}
+}
+
+// Begin implements idempotency.Store. The primary key makes the reservation atomic.
+func (s *IdempotencyStore) Begin(
+	ctx context.Context, key, requestHash string, ttl time.Duration,
+) (idempotency.Record, bool, error) {
+	now := time.Now()
+	if s.started.Add(1)%idempotencyCleanupEvery == 0 {
+		s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&idempotencyRecord{})
+	}
+
+	// An expired record only blocks the key until it is replaced here.
+	err := s.db.WithContext(ctx).Where("record_key = ? AND expires_at <= ?", key, now).Delete(&idempotencyRecord{}).Error
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("failed to delete expired idempotency record: %w", err)
+	}
+
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&idempotencyRecord{
+		RecordKey:   key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(ttl),
+	})
+	if res.Error != nil {
+		return idempotency.Record{}, false, fmt.Errorf("failed to reserve idempotency key: %w", res.Error)
+	}
+	if res.RowsAffected == 1 {
+		return idempotency.Record{}, true, nil
+	}
+
+	var row idempotencyRecord
+	if err := s.db.WithContext(ctx).Where("record_key = ?", key).Take(&row).Error; err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("failed to retrieve idempotency record: %w", err)
+	}
+	rec := idempotency.Record{RequestHash: row.RequestHash, ExpiresAt: row.ExpiresAt}
+	if row.StatusCode != 0 {
+		rec.Response = &idempotency.Response{Status: row.StatusCode, ContentType: row.ContentType, Body: row.Body}
+	}
+	return rec, false, nil
+}
+
+// Complete implements idempotency.Store.
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, resp idempotency.Response) error {
+	err := s.db.WithContext(ctx).Model(&idempotencyRecord{}).
+		Where("record_key = ?", key).
+		Updates(map[string]any{"status_code": resp.Status, "content_type": resp.ContentType, "body": resp.Body}).Error
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Abort implements idempotency.Store.
+func (s *IdempotencyStore) Abort(ctx context.Context, key string) error {
+	if err := s.db.WithContext(ctx).Where("record_key = ?", key).Delete(&idempotencyRecord{}).Error; err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -6,24 +6,34 @@ import (
 	"fmt"
 	"pack_optimizer/internal/domain"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var tracer = otel.Tracer("pack_optimizer/internal/repository/sqlrepo")
+
 // PackRepo is a repository that provides methods to interact with the Pack data in the database.
 type PackRepo struct {
 	db *gorm.DB // db is the GORM database connection.
 }
 
 // NewPackRepo creates a new instance of PackRepo.
-func NewPackRepo(db *gorm.DB) domain.PackRepository {
+func NewPackRepo(db *gorm.DB) domain.PackSetRepository {
 	return &PackRepo{db: db}
 }
 
 // GetAllPacks retrieves all packs from the database, ordered by size in ascending order.
 func (r *PackRepo) GetAllPacks(ctx context.Context) ([]domain.Pack, error) {
+	ctx, span := tracer.Start(ctx, "PackRepo.GetAllPacks")
+	defer span.End()
+
 	var packs []domain.Pack
 	err := r.db.WithContext(ctx).Select("size").Order("size ASC").Find(&packs).Error
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		// wrapping the error to provide more context
 		return nil, fmt.Errorf("failed to retrieve packs: %w", err)
 	}
@@ -32,3 +42,34 @@ func (r *PackRepo) GetAllPacks(ctx context.Context) ([]domain.Pack, error) {
 	}
 	return packs, nil
 }
+
+// UpdatePacks adds and removes pack sizes in a single transaction.
+func (r *PackRepo) UpdatePacks(ctx context.Context, add, remove []int) error {
+	ctx, span := tracer.Start(ctx, "PackRepo.UpdatePacks")
+	defer span.End()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(remove) > 0 {
+			if err := tx.Where("size IN ?", remove).Delete(&domain.Pack{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(add) > 0 {
+			packs := make([]domain.Pack, len(add))
+			for i, size := range add {
+				packs[i] = domain.Pack{Size: size}
+			}
+			// Sizes that already exist are skipped, so the update can be repeated safely.
+			if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "size"}}, DoNothing: true}).Create(&packs).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to update packs: %w", err)
+	}
+	return nil
+}
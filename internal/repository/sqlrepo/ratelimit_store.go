@@ -0,0 +1,111 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pack_optimizer/pkg/ratelimit"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bucketIdleTTL is how long a bucket may stay untouched before DeleteIdle removes it.
+const bucketIdleTTL = 24 * time.Hour
+
+// rateLimitBucket is one row of the rate_limit_buckets table.
+type rateLimitBucket struct {
+	BucketKey string    `gorm:"primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+}
+
+func (rateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// RateLimitStore keeps token buckets in the database, so every server instance shares them.
+type RateLimitStore struct {
+	db *gorm.DB // db is the GORM database connection.
+}
+
+// NewRateLimitStore creates a new instance of RateLimitStore.
+func NewRateLimitStore(db *gorm.DB) *RateLimitStore {
+	return &RateLimitStore{db: db}
+}
+
+// DeleteIdle deletes the buckets that were not used for a day. They would have refilled long ago,
+// and Take recreates them full on demand. It is meant to run periodically in the background.
+func (s *RateLimitStore) DeleteIdle(ctx context.Context) error {
+	err := s.db.WithContext(ctx).Where("updated_at < ?", time.Now().Add(-bucketIdleTTL)).Delete(&rateLimitBucket{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+	return nil
+}
+
+// Take implements ratelimit.Store. The bucket rows are locked for the duration of the transaction
+// on Postgres, in key order so that concurrent requests can not deadlock; SQLite serialises write
+// transactions on its own.
+func (s *RateLimitStore) Take(ctx context.Context, charges ...ratelimit.Charge) ([]ratelimit.Result, error) {
+	order := make([]int, len(charges))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return charges[order[a]].Key < charges[order[b]].Key })
+
+	var results []ratelimit.Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		states := make([]ratelimit.State, len(charges))
+		for _, i := range order {
+			bucket, err := s.lockOrCreateBucket(tx, charges[i], now)
+			if err != nil {
+				return err
+			}
+			states[i] = ratelimit.State{Tokens: bucket.Tokens, Last: bucket.UpdatedAt}
+		}
+
+		var next []ratelimit.State
+		next, results = ratelimit.TakeAll(states, now, charges)
+		for i, charge := range charges {
+			err := tx.Model(&rateLimitBucket{}).
+				Where("bucket_key = ?", charge.Key).
+				Updates(map[string]any{"tokens": next[i].Tokens, "updated_at": next[i].Last}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to take from rate limit buckets: %w", err)
+	}
+	return results, nil
+}
+
+// lockOrCreateBucket locks the bucket of charge, creating it full if it does not exist yet.
+func (s *RateLimitStore) lockOrCreateBucket(tx *gorm.DB, charge ratelimit.Charge, now time.Time) (rateLimitBucket, error) {
+	bucket, err := s.lockBucket(tx, charge.Key)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return bucket, err
+	}
+	// Another instance may create the same bucket concurrently, so ignore conflicts and lock again.
+	err = tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rateLimitBucket{BucketKey: charge.Key, Tokens: charge.Limit.Burst, UpdatedAt: now}).Error
+	if err != nil {
+		return rateLimitBucket{}, err
+	}
+	return s.lockBucket(tx, charge.Key)
+}
+
+func (s *RateLimitStore) lockBucket(tx *gorm.DB, key string) (rateLimitBucket, error) {
+	if tx.Dialector.Name() == "postgres" {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var bucket rateLimitBucket
+	err := tx.Where("bucket_key = ?", key).Take(&bucket).Error
+	return bucket, err
+}
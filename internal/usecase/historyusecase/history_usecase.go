@@ -0,0 +1,135 @@
+// Package historyusecase provides methods for recording pack calculations and looking them up later.
+package historyusecase
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/packusecase"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Page sizes of List.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// HistoryUseCase is a use case that keeps the history of pack calculations.
+type HistoryUseCase struct {
+	repo  domain.CalculationRepository // repo is the repository interface for storing calculations.
+	now   func() time.Time
+	newID func() string
+}
+
+// NewHistoryUseCase creates a new instance of HistoryUseCase.
+// Parameters:
+//   - repo: An implementation of the domain.CalculationRepository interface.
+//
+// Returns:
+//   - A pointer to a new HistoryUseCase instance.
+func NewHistoryUseCase(repo domain.CalculationRepository) *HistoryUseCase {
+	return &HistoryUseCase{repo: repo, now: time.Now, newID: uuid.NewString}
+}
+
+// Record stores the result of a calculation under a new random ID.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - quantity: The ordered quantity.
+//   - opts: The options the calculation was run with.
+//   - output: The result of the calculation.
+//
+// Returns:
+//   - The recorded calculation, including its ID.
+//   - An error if the calculation cannot be stored.
+func (uc *HistoryUseCase) Record(
+	ctx context.Context, quantity int, opts packusecase.CalculateOptions, output packusecase.CalculatePacksOutput,
+) (domain.Calculation, error) {
+	calculation := domain.Calculation{
+		ID:         uc.newID(),
+		Quantity:   quantity,
+		ExactOnly:  opts.ExactOnly,
+		TotalItems: output.TotalItems,
+		TotalPacks: output.TotalPacks,
+		Difference: output.Difference,
+		Packs:      make([]domain.CalculationPack, len(output.Packs)),
+		CreatedAt:  uc.now().UTC(),
+	}
+	if tolerance := opts.Tolerance; tolerance != nil {
+		calculation.Tolerance = domain.CalculationTolerance{Under: tolerance.Under, Over: tolerance.Over, Unit: string(tolerance.Unit)}
+		if calculation.Tolerance.Unit == "" {
+			calculation.Tolerance.Unit = string(packusecase.ToleranceAbsolute)
+		}
+	}
+	for i, pack := range output.Packs {
+		calculation.Packs[i] = domain.CalculationPack{Size: pack.Size, Count: pack.Count}
+	}
+	if err := uc.repo.CreateCalculation(ctx, &calculation); err != nil {
+		return domain.Calculation{}, err
+	}
+	return calculation, nil
+}
+
+// Get returns one recorded calculation.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - id: The ID the calculation was recorded under.
+//
+// Returns:
+//   - The recorded calculation.
+//   - domain.ErrCalculationNotFound if no calculation has the ID, or an error if it cannot be read.
+func (uc *HistoryUseCase) Get(ctx context.Context, id string) (domain.Calculation, error) {
+	if uuid.Validate(id) != nil {
+		return domain.Calculation{}, domain.ErrCalculationNotFound
+	}
+	return uc.repo.GetCalculation(ctx, id)
+}
+
+// Prune deletes the calculations recorded more than retention ago.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - retention: How long calculations are kept.
+//
+// Returns:
+//   - The number of deleted calculations.
+//   - An error if the calculations cannot be deleted.
+func (uc *HistoryUseCase) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	return uc.repo.DeleteCalculationsOlderThan(ctx, uc.now().UTC().Add(-retention))
+}
+
+// List returns the recorded calculations matching filter, most recent first.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - filter: The calculations to return. A zero Limit returns DefaultLimit calculations.
+//
+// Returns:
+//   - The matching calculations; none is an empty slice, not an error.
+//   - domain.ErrInvalidRange if the quantity or date range is reversed or Limit exceeds MaxLimit,
+//     or an error if the calculations cannot be read.
+func (uc *HistoryUseCase) List(ctx context.Context, filter domain.CalculationFilter) ([]domain.Calculation, error) {
+	if filter.MaxQuantity > 0 && filter.MinQuantity > filter.MaxQuantity {
+		return nil, fmt.Errorf("%w: minimum quantity %d is greater than maximum quantity %d",
+			domain.ErrInvalidRange, filter.MinQuantity, filter.MaxQuantity)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: the start date must be before the end date", domain.ErrInvalidRange)
+	}
+	if filter.Limit < 0 || filter.Limit > MaxLimit || filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: page size must be between 1 and %d", domain.ErrInvalidRange, MaxLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultLimit
+	}
+	filter.From, filter.To = filter.From.UTC(), filter.To.UTC()
+
+	calculations, err := uc.repo.ListCalculations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if calculations == nil {
+		calculations = []domain.Calculation{}
+	}
+	return calculations, nil
+}
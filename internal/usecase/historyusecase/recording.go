@@ -0,0 +1,52 @@
+package historyusecase
+
+import (
+	"context"
+	"pack_optimizer/internal/usecase/packusecase"
+	"pack_optimizer/pkg/logpkg"
+)
+
+// PackService is the pack use case whose calculations are recorded. It is implemented by
+// packusecase.PackUseCase and packusecase.AdmittedPackUseCase.
+type PackService interface {
+	CalculatePacksWithOptions(ctx context.Context, orderQty int, opts packusecase.CalculateOptions) (packusecase.CalculatePacksOutput, error)
+	ParetoFront(ctx context.Context, orderQty, maxPoints int) (packusecase.ParetoOutput, error)
+	ReverseLookup(ctx context.Context, combination map[int]int) (packusecase.ReverseLookupOutput, error)
+	NewSweeper(ctx context.Context, from, to, step int) (*packusecase.Sweeper, error)
+}
+
+// RecordingPackUseCase wraps a PackService so that every successful calculation is recorded in
+// the history. Pareto fronts, reverse lookups and sweeps are passed through unrecorded.
+type RecordingPackUseCase struct {
+	PackService
+	history *HistoryUseCase
+}
+
+// NewRecordingPackUseCase creates a new instance of RecordingPackUseCase.
+// Parameters:
+//   - uc: The use case that runs the calculations.
+//   - history: The history the results are recorded in.
+//
+// Returns:
+//   - A pointer to a new RecordingPackUseCase instance.
+func NewRecordingPackUseCase(uc PackService, history *HistoryUseCase) *RecordingPackUseCase {
+	return &RecordingPackUseCase{PackService: uc, history: history}
+}
+
+// CalculatePacksWithOptions runs the calculation and records its result, setting the ID of the output.
+// A result that cannot be recorded is still returned, without an ID, since the calculation itself succeeded.
+func (r *RecordingPackUseCase) CalculatePacksWithOptions(
+	ctx context.Context, orderQty int, opts packusecase.CalculateOptions,
+) (packusecase.CalculatePacksOutput, error) {
+	output, err := r.PackService.CalculatePacksWithOptions(ctx, orderQty, opts)
+	if err != nil {
+		return output, err
+	}
+	calculation, err := r.history.Record(ctx, orderQty, opts, output)
+	if err != nil {
+		logpkg.FromContext(ctx).Warn().Err(err).Msg("failed to record calculation")
+		return output, nil
+	}
+	output.ID = calculation.ID
+	return output, nil
+}
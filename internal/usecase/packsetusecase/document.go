@@ -0,0 +1,124 @@
+package packsetusecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"pack_optimizer/internal/domain"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentVersion is the version of the pack set document written by Export. Import rejects
+// documents of any other version, so a newer format is never half-understood.
+const DocumentVersion = 1
+
+// Document formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Document is a pack set as exported from one environment and imported into another.
+type Document struct {
+	Version    int            `json:"version" yaml:"version"`
+	ExportedAt time.Time      `json:"exported_at,omitzero" yaml:"exported_at,omitempty"`
+	Source     string         `json:"source,omitempty" yaml:"source,omitempty"` // Environment the set was exported from
+	Packs      []DocumentPack `json:"packs" yaml:"packs"`
+}
+
+// DocumentPack is one pack of a Document. It is an object, not a bare size, so later versions can add metadata.
+type DocumentPack struct {
+	Size int `json:"size" yaml:"size"`
+}
+
+// ImportOptions controls how a Document is imported.
+type ImportOptions struct {
+	Merge  bool // Keep stored sizes missing from the document; by default the stored set is replaced
+	DryRun bool // Only compute the diff
+}
+
+// Export returns the stored pack set as a Document.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - source: The name of the environment, recorded in the document.
+//
+// Returns:
+//   - A Document of the current version holding every stored size; an empty set is not an error.
+//   - An error if the packs cannot be read.
+func (uc *PackSetUseCase) Export(ctx context.Context, source string) (Document, error) {
+	sizes, err := uc.currentSizes(ctx)
+	if err != nil {
+		return Document{}, err
+	}
+	doc := Document{Version: DocumentVersion, ExportedAt: uc.now().UTC(), Source: source, Packs: []DocumentPack{}}
+	for _, size := range sizes {
+		doc.Packs = append(doc.Packs, DocumentPack{Size: size})
+	}
+	return doc, nil
+}
+
+// Import makes the stored pack set match doc.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - doc: The document to import.
+//   - opts: Whether stored sizes missing from doc are kept and whether anything is written.
+//
+// Returns:
+//   - The Diff between the stored sizes and the document.
+//   - domain.ErrInvalidPackSet if the document version is not supported or its sizes are invalid,
+//     or an error if the packs cannot be read or written.
+func (uc *PackSetUseCase) Import(ctx context.Context, doc Document, opts ImportOptions) (Diff, error) {
+	if doc.Version != DocumentVersion {
+		return Diff{}, fmt.Errorf("%w: document version %d is not supported, expected %d",
+			domain.ErrInvalidPackSet, doc.Version, DocumentVersion)
+	}
+	sizes := make([]int, len(doc.Packs))
+	for i, pack := range doc.Packs {
+		sizes[i] = pack.Size
+	}
+	return uc.Apply(ctx, sizes, ApplyOptions{Prune: !opts.Merge, DryRun: opts.DryRun})
+}
+
+// DecodeDocument parses a JSON or YAML document. Unknown fields are rejected so typos are not ignored.
+func DecodeDocument(data []byte, format string) (Document, error) {
+	var doc Document
+	var err error
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&doc)
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&doc)
+	default:
+		return Document{}, fmt.Errorf("%w: unknown document format %q", domain.ErrInvalidPackSet, format)
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("%w: %s", domain.ErrInvalidPackSet, err)
+	}
+	return doc, nil
+}
+
+// EncodeDocument writes doc as JSON or YAML.
+func EncodeDocument(w io.Writer, doc Document, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown document format %q", format)
+}
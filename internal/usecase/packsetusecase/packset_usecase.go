@@ -0,0 +1,155 @@
+// Package packsetusecase provides methods for changing the pack sizes on offer.
+package packsetusecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"slices"
+	"time"
+)
+
+// ApplyOptions controls how a pack set is applied.
+type ApplyOptions struct {
+	Prune  bool // Remove sizes that are not in the new set; otherwise they are kept
+	DryRun bool // Only compute the diff and leave the stored sizes unchanged
+}
+
+// Diff describes how a pack set differs from the stored sizes. Every slice is sorted ascending.
+type Diff struct {
+	Added     []int `json:"added"`
+	Removed   []int `json:"removed"`
+	Unchanged []int `json:"unchanged"`
+}
+
+// Changed reports whether applying the pack set adds or removes any size.
+func (d Diff) Changed() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0
+}
+
+// PackSetUseCase is a use case that replaces or extends the stored pack sizes.
+type PackSetUseCase struct {
+	repo        domain.PackSetRepository // repo is the repository interface for reading and changing packs.
+	now         func() time.Time
+	maxPackSize int // Largest pack size accepted; 0 means no limit
+}
+
+// Option configures a PackSetUseCase.
+type Option func(*PackSetUseCase)
+
+// WithMaxPackSize rejects pack sizes above maxPackSize. The solver's work and a sweep's table
+// grow with the largest pack size, so a typo such as 1000000000 must not reach them.
+func WithMaxPackSize(maxPackSize int) Option {
+	return func(uc *PackSetUseCase) {
+		uc.maxPackSize = maxPackSize
+	}
+}
+
+// NewPackSetUseCase creates a new instance of PackSetUseCase.
+// Parameters:
+//   - repo: An implementation of the domain.PackSetRepository interface.
+//   - opts: Optional settings such as WithMaxPackSize.
+//
+// Returns:
+//   - A pointer to a new PackSetUseCase instance.
+func NewPackSetUseCase(repo domain.PackSetRepository, opts ...Option) *PackSetUseCase {
+	uc := &PackSetUseCase{repo: repo, now: time.Now}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// Apply makes the stored sizes contain sizes. Applying the same set twice changes nothing the second time.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - sizes: The pack sizes to store. Duplicates are ignored.
+//   - opts: Whether sizes missing from the set are removed and whether anything is written.
+//
+// Returns:
+//   - The Diff between the stored sizes and the set.
+//   - domain.ErrInvalidPackSet if a size is not positive, exceeds the maximum pack size or pruning
+//     would leave no sizes, or an error if the packs cannot be read or written.
+func (uc *PackSetUseCase) Apply(ctx context.Context, sizes []int, opts ApplyOptions) (Diff, error) {
+	wanted, err := uc.normalize(sizes)
+	if err != nil {
+		return Diff{}, err
+	}
+	if opts.Prune && len(wanted) == 0 {
+		return Diff{}, fmt.Errorf("%w: at least one pack size is required", domain.ErrInvalidPackSet)
+	}
+
+	current, err := uc.currentSizes(ctx)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	diff := Diff{Added: []int{}, Removed: []int{}, Unchanged: []int{}}
+	for _, size := range wanted {
+		if slices.Contains(current, size) {
+			diff.Unchanged = append(diff.Unchanged, size)
+		} else {
+			diff.Added = append(diff.Added, size)
+		}
+	}
+	for _, size := range current {
+		if slices.Contains(wanted, size) {
+			continue
+		}
+		if opts.Prune {
+			diff.Removed = append(diff.Removed, size)
+		} else {
+			diff.Unchanged = append(diff.Unchanged, size)
+		}
+	}
+	slices.Sort(diff.Unchanged)
+
+	if opts.DryRun || !diff.Changed() {
+		return diff, nil
+	}
+	if err := uc.repo.UpdatePacks(ctx, diff.Added, diff.Removed); err != nil {
+		return Diff{}, err
+	}
+	return diff, nil
+}
+
+// currentSizes returns the stored sizes in ascending order; no packs is an empty set, not an error.
+func (uc *PackSetUseCase) currentSizes(ctx context.Context) ([]int, error) {
+	packs, err := uc.repo.GetAllPacks(ctx)
+	if errors.Is(err, domain.ErrNoPacksAvailable) {
+		return []int{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int, len(packs))
+	for i, pack := range packs {
+		sizes[i] = pack.Size
+	}
+	slices.Sort(sizes)
+	return sizes, nil
+}
+
+// normalize validates sizes and returns them sorted without duplicates.
+func (uc *PackSetUseCase) normalize(sizes []int) ([]int, error) {
+	out := slices.Clone(sizes)
+	for _, size := range out {
+		if err := uc.checkSize(size); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// checkSize validates a single pack size against the bounds every stored size must respect.
+func (uc *PackSetUseCase) checkSize(size int) error {
+	if size <= 0 {
+		return fmt.Errorf("%w: pack size %d must be greater than 0", domain.ErrInvalidPackSet, size)
+	}
+	if uc.maxPackSize > 0 && size > uc.maxPackSize {
+		return fmt.Errorf("%w: pack size %d must not exceed %d", domain.ErrInvalidPackSet, size, uc.maxPackSize)
+	}
+	return nil
+}
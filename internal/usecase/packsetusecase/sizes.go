@@ -0,0 +1,169 @@
+package packsetusecase
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"slices"
+)
+
+// Sizes returns the stored pack sizes.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//
+// Returns:
+//   - The stored sizes in ascending order; no sizes is an empty slice, not an error.
+//   - An error if the packs cannot be read.
+func (uc *PackSetUseCase) Sizes(ctx context.Context) ([]int, error) {
+	return uc.currentSizes(ctx)
+}
+
+// AddSize stores one new pack size.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - size: The pack size to add.
+//
+// Returns:
+//   - domain.ErrInvalidPackSet if size is not positive, exceeds the maximum pack size or is already stored,
+//     or an error if the packs cannot be read or written.
+func (uc *PackSetUseCase) AddSize(ctx context.Context, size int) error {
+	current, err := uc.currentSizes(ctx)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkNewSize(current, size); err != nil {
+		return err
+	}
+	return uc.repo.UpdatePacks(ctx, []int{size}, nil)
+}
+
+// PlanReplace validates replacing the stored pack size old with size without changing anything,
+// so the change can be confirmed before ReplaceSize applies it.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - old: The stored pack size to replace.
+//   - size: The pack size that takes its place.
+//
+// Returns:
+//   - The Diff that ReplaceSize would apply.
+//   - domain.ErrUnknownPackSize if old is not stored.
+//   - domain.ErrInvalidPackSet if size is not positive, exceeds the maximum pack size or is already stored,
+//     or an error if the packs cannot be read.
+func (uc *PackSetUseCase) PlanReplace(ctx context.Context, old, size int) (Diff, error) {
+	current, err := uc.currentSizes(ctx)
+	if err != nil {
+		return Diff{}, err
+	}
+	if !slices.Contains(current, old) {
+		return Diff{}, fmt.Errorf("%w: %d", domain.ErrUnknownPackSize, old)
+	}
+	if size != old {
+		if err := uc.checkNewSize(current, size); err != nil {
+			return Diff{}, err
+		}
+	}
+	return diffOf(current, size, old), nil
+}
+
+// ReplaceSize replaces the stored pack size old with size in a single update.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - old: The stored pack size to replace.
+//   - size: The pack size that takes its place.
+//
+// Returns:
+//   - The applied Diff; replacing a size with itself changes nothing.
+//   - The errors of PlanReplace, or an error if the packs cannot be written.
+func (uc *PackSetUseCase) ReplaceSize(ctx context.Context, old, size int) (Diff, error) {
+	diff, err := uc.PlanReplace(ctx, old, size)
+	if err != nil {
+		return Diff{}, err
+	}
+	if err := uc.update(ctx, diff); err != nil {
+		return Diff{}, err
+	}
+	return diff, nil
+}
+
+// PlanRemove validates removing one stored pack size without changing anything,
+// so the removal can be confirmed before RemoveSize applies it.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - size: The pack size to remove.
+//
+// Returns:
+//   - The Diff that RemoveSize would apply.
+//   - domain.ErrUnknownPackSize if size is not stored.
+//   - domain.ErrInvalidPackSet if size is the last stored size, since orders could no longer be packed,
+//     or an error if the packs cannot be read.
+func (uc *PackSetUseCase) PlanRemove(ctx context.Context, size int) (Diff, error) {
+	current, err := uc.currentSizes(ctx)
+	if err != nil {
+		return Diff{}, err
+	}
+	if !slices.Contains(current, size) {
+		return Diff{}, fmt.Errorf("%w: %d", domain.ErrUnknownPackSize, size)
+	}
+	if len(current) == 1 {
+		return Diff{}, fmt.Errorf("%w: at least one pack size is required", domain.ErrInvalidPackSet)
+	}
+	return diffOf(current, 0, size), nil
+}
+
+// RemoveSize removes one stored pack size.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - size: The pack size to remove.
+//
+// Returns:
+//   - The applied Diff.
+//   - The errors of PlanRemove, or an error if the packs cannot be written.
+func (uc *PackSetUseCase) RemoveSize(ctx context.Context, size int) (Diff, error) {
+	diff, err := uc.PlanRemove(ctx, size)
+	if err != nil {
+		return Diff{}, err
+	}
+	if err := uc.update(ctx, diff); err != nil {
+		return Diff{}, err
+	}
+	return diff, nil
+}
+
+// update writes diff unless it changes nothing.
+func (uc *PackSetUseCase) update(ctx context.Context, diff Diff) error {
+	if !diff.Changed() {
+		return nil
+	}
+	return uc.repo.UpdatePacks(ctx, diff.Added, diff.Removed)
+}
+
+// diffOf returns the Diff of adding add to current and removing remove from it; a zero add or remove is skipped.
+func diffOf(current []int, add, remove int) Diff {
+	diff := Diff{Added: []int{}, Removed: []int{}, Unchanged: []int{}}
+	if add == remove {
+		diff.Unchanged = slices.Clone(current)
+		return diff
+	}
+	if add != 0 {
+		diff.Added = append(diff.Added, add)
+	}
+	for _, size := range current {
+		if size == remove {
+			diff.Removed = append(diff.Removed, size)
+		} else {
+			diff.Unchanged = append(diff.Unchanged, size)
+		}
+	}
+	return diff
+}
+
+// checkNewSize validates a size that is about to be added to current.
+func (uc *PackSetUseCase) checkNewSize(current []int, size int) error {
+	if err := uc.checkSize(size); err != nil {
+		return err
+	}
+	if slices.Contains(current, size) {
+		return fmt.Errorf("%w: pack size %d already exists", domain.ErrInvalidPackSet, size)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package packusecase
+
+import (
+	"context"
+	"errors"
+	"pack_optimizer/pkg/admission"
+)
+
+// AdmittedPackUseCase wraps a PackUseCase so that every solve first takes a slot from an
+// admission controller. Requests are refused with an *OverloadError when the controller's
+// queue is full or no slot frees up in time, instead of piling up on the CPU.
+type AdmittedPackUseCase struct {
+	uc        *PackUseCase
+	admission *admission.Controller
+}
+
+// NewAdmittedPackUseCase creates a new instance of AdmittedPackUseCase.
+// Parameters:
+//   - uc: The use case that runs the solves.
+//   - controller: The admission controller bounding concurrent solves.
+//
+// Returns:
+//   - A pointer to a new AdmittedPackUseCase instance.
+func NewAdmittedPackUseCase(uc *PackUseCase, controller *admission.Controller) *AdmittedPackUseCase {
+	return &AdmittedPackUseCase{uc: uc, admission: controller}
+}
+
+// CalculatePacks calls PackUseCase.CalculatePacks once a slot is free.
+func (a *AdmittedPackUseCase) CalculatePacks(ctx context.Context, orderQty int) (CalculatePacksOutput, error) {
+	return a.CalculatePacksWithOptions(ctx, orderQty, CalculateOptions{})
+}
+
+// CalculatePacksWithOptions calls PackUseCase.CalculatePacksWithOptions once a slot is free.
+func (a *AdmittedPackUseCase) CalculatePacksWithOptions(
+	ctx context.Context, orderQty int, opts CalculateOptions,
+) (CalculatePacksOutput, error) {
+	release, err := a.acquire(ctx)
+	if err != nil {
+		return CalculatePacksOutput{}, err
+	}
+	defer release()
+	return a.uc.CalculatePacksWithOptions(ctx, orderQty, opts)
+}
+
+// ParetoFront calls PackUseCase.ParetoFront once a slot is free.
+func (a *AdmittedPackUseCase) ParetoFront(ctx context.Context, orderQty, maxPoints int) (ParetoOutput, error) {
+	release, err := a.acquire(ctx)
+	if err != nil {
+		return ParetoOutput{}, err
+	}
+	defer release()
+	return a.uc.ParetoFront(ctx, orderQty, maxPoints)
+}
+
+// ReverseLookup calls PackUseCase.ReverseLookup once a slot is free.
+func (a *AdmittedPackUseCase) ReverseLookup(ctx context.Context, combination map[int]int) (ReverseLookupOutput, error) {
+	release, err := a.acquire(ctx)
+	if err != nil {
+		return ReverseLookupOutput{}, err
+	}
+	defer release()
+	return a.uc.ReverseLookup(ctx, combination)
+}
+
+// NewSweeper calls PackUseCase.NewSweeper once a slot is free. The slot is held until the
+// returned Sweeper's Run has computed the rows, since that is where the table is built, and is
+// released before the rows are emitted.
+func (a *AdmittedPackUseCase) NewSweeper(ctx context.Context, from, to, step int) (*Sweeper, error) {
+	release, err := a.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sweeper, err := a.uc.NewSweeper(ctx, from, to, step)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	sweeper.release = release
+	return sweeper, nil
+}
+
+func (a *AdmittedPackUseCase) acquire(ctx context.Context) (func(), error) {
+	release, err := a.admission.Acquire(ctx)
+	if errors.Is(err, admission.ErrQueueFull) || errors.Is(err, admission.ErrWaitTimeout) {
+		return nil, &OverloadError{Reason: err, RetryAfter: a.admission.MaxWait()}
+	}
+	return release, err
+}
@@ -1,6 +1,11 @@
 // Package packusecase provides use cases for managing packs.
 package packusecase
 
+import "context"
+
+// extendCheckEvery is the number of totals filled between checks for a cancelled context.
+const extendCheckEvery = 1 << 16
+
 // Node represents a state: total items, total packs, pack count
 type Node struct {
 	totalItems int   // total items shipped so far
@@ -45,3 +50,62 @@ func (pq *PriorityQueue) Pop() any {
 	*pq = old[:n-1]
 	return node
 }
+
+// packTable is a bottom-up table of the fewest packs needed to reach each total exactly.
+// It grows on demand, so a caller walking through increasing quantities only pays for
+// the totals it actually reaches.
+type packTable struct {
+	sizes    []int   // pack sizes in ascending order
+	minPacks []int32 // fewest packs summing exactly to each total, -1 if unreachable
+	lastPack []int32 // index into sizes of the last pack added to reach each total
+}
+
+func newPackTable(sizes []int) *packTable {
+	return &packTable{
+		sizes:    sizes,
+		minPacks: []int32{0},
+		lastPack: []int32{-1},
+	}
+}
+
+// extend fills the table up to and including limit. It stops early with the context's error
+// once ctx is done, leaving the totals filled so far in place.
+func (t *packTable) extend(ctx context.Context, limit int) error {
+	for total := len(t.minPacks); total <= limit; total++ {
+		if total%extendCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		best, last := int32(-1), int32(-1)
+		for i, size := range t.sizes {
+			if size > total {
+				break
+			}
+			if prev := t.minPacks[total-size]; prev >= 0 && (best < 0 || prev+1 < best) {
+				best, last = prev+1, int32(i)
+			}
+		}
+		t.minPacks = append(t.minPacks, best)
+		t.lastPack = append(t.lastPack, last)
+	}
+	return nil
+}
+
+// reachable reports whether total can be made of whole packs. The table must cover total.
+func (t *packTable) reachable(total int) bool {
+	return t.minPacks[total] >= 0
+}
+
+// node rebuilds the combination with the fewest packs for a reachable total.
+func (t *packTable) node(total int) *Node {
+	n := &Node{
+		totalItems: total,
+		totalPacks: int(t.minPacks[total]),
+		packCount:  make([]int, len(t.sizes)),
+	}
+	for rest := total; rest > 0; rest -= t.sizes[t.lastPack[rest]] {
+		n.packCount[t.lastPack[rest]]++
+	}
+	return n
+}
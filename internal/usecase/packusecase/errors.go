@@ -0,0 +1,52 @@
+package packusecase
+
+import (
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"time"
+)
+
+// NoFitError is returned when no pack combination lands inside the acceptable window
+// around the order quantity (exact-only mode or a tolerance window).
+// It carries the nearest quantities that can be shipped so callers can suggest them.
+type NoFitError struct {
+	OrderQty     int
+	MinItems     int // Smallest acceptable total
+	MaxItems     int // Largest acceptable total
+	NearestBelow int // Largest reachable quantity below the order, 0 if there is none
+	NearestAbove int // Smallest reachable quantity at or above the order
+}
+
+func (e *NoFitError) Error() string {
+	if e.exact() {
+		return fmt.Sprintf("no pack combination adds up to exactly %d items", e.OrderQty)
+	}
+	return fmt.Sprintf("no pack combination between %d and %d items", e.MinItems, e.MaxItems)
+}
+
+// Unwrap allows errors.Is(err, domain.ErrNoExactFit) and errors.Is(err, domain.ErrOutsideTolerance).
+func (e *NoFitError) Unwrap() error {
+	if e.exact() {
+		return domain.ErrNoExactFit
+	}
+	return domain.ErrOutsideTolerance
+}
+
+func (e *NoFitError) exact() bool {
+	return e.MinItems == e.OrderQty && e.MaxItems == e.OrderQty
+}
+
+// OverloadError is returned when admission control refuses to start a solve.
+type OverloadError struct {
+	Reason     error         // Why the solve was refused, e.g. admission.ErrQueueFull
+	RetryAfter time.Duration // When the caller should try again
+}
+
+func (e *OverloadError) Error() string {
+	return fmt.Sprintf("%s: %s", domain.ErrOverloaded.Message, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, domain.ErrOverloaded).
+func (e *OverloadError) Unwrap() error {
+	return domain.ErrOverloaded
+}
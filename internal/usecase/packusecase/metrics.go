@@ -0,0 +1,51 @@
+package packusecase
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	solveDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pack_optimizer_solve_duration_seconds",
+		Help:    "Time spent searching for pack combinations, by operation.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"operation"})
+
+	solveStatesExplored = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pack_optimizer_solve_states_explored",
+		Help:    "Number of search states popped from the priority queue per solve, by operation.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 12),
+	}, []string{"operation"})
+
+	solveQueuePeak = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pack_optimizer_solve_queue_peak_size",
+		Help:    "Largest priority queue size reached per solve, by operation.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 12),
+	}, []string{"operation"})
+
+	getAllPacksDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pack_optimizer_db_get_all_packs_duration_seconds",
+		Help:    "Latency of loading the pack sizes from the repository.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// searchStats collects counters from a single priority queue search.
+type searchStats struct {
+	statesExplored int // nodes popped from the queue
+	queuePeak      int // largest queue length seen
+	maxStates      int // states the search may explore before it gives up; 0 means no limit
+}
+
+// exhausted reports whether the search has explored more states than its budget allows.
+func (s *searchStats) exhausted() bool {
+	return s.maxStates > 0 && s.statesExplored > s.maxStates
+}
+
+// observe records the stats and the elapsed time of one solve under the given operation label.
+func (s *searchStats) observe(operation string, seconds float64) {
+	solveDuration.WithLabelValues(operation).Observe(seconds)
+	solveStatesExplored.WithLabelValues(operation).Observe(float64(s.statesExplored))
+	solveQueuePeak.WithLabelValues(operation).Observe(float64(s.queuePeak))
+}
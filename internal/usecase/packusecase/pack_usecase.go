@@ -7,22 +7,47 @@ import (
 	"errors"
 	"fmt"
 	"pack_optimizer/internal/domain"
+	"pack_optimizer/pkg/logpkg"
 	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("pack_optimizer/internal/usecase/packusecase")
+
 // PackUseCase is a use case that provides methods to calculate the optimal pack combinations.
 type PackUseCase struct {
 	packRepo domain.PackRepository // packRepo is the repository interface for accessing pack data.
+	limits   Limits
+}
+
+// Option configures a PackUseCase.
+type Option func(*PackUseCase)
+
+// WithLimits bounds the quantities accepted and the work done per solve.
+func WithLimits(limits Limits) Option {
+	return func(uc *PackUseCase) {
+		uc.limits = limits
+	}
 }
 
 // NewPackUseCase creates a new instance of PackUseCase.
 // Parameters:
 //   - packRepo: An implementation of the domain.PackRepository interface.
+//   - opts: Optional settings such as WithLimits.
 //
 // Returns:
 //   - A pointer to a new PackUseCase instance.
-func NewPackUseCase(packRepo domain.PackRepository) *PackUseCase {
-	return &PackUseCase{packRepo: packRepo}
+func NewPackUseCase(packRepo domain.PackRepository, opts ...Option) *PackUseCase {
+	uc := &PackUseCase{packRepo: packRepo}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 // CalculatePacks calculates the optimal combination of packs to fulfill an order quantity.
@@ -34,29 +59,99 @@ func NewPackUseCase(packRepo domain.PackRepository) *PackUseCase {
 //   - A CalculatePacksOutput struct containing the details of the calculated packs.
 //   - An error if the operation fails or the input is invalid.
 func (uc *PackUseCase) CalculatePacks(ctx context.Context, orderQty int) (CalculatePacksOutput, error) {
+	return uc.CalculatePacksWithOptions(ctx, orderQty, CalculateOptions{})
+}
+
+// CalculatePacksWithOptions calculates the optimal combination of packs under the given options.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - orderQty: The quantity of items to fulfill in the order.
+//   - opts: Options restricting which combinations are acceptable.
+//
+// Returns:
+//   - A CalculatePacksOutput struct containing the details of the calculated packs.
+//   - A *NoFitError if no combination lies inside the window allowed by opts.ExactOnly or opts.Tolerance.
+//   - An error if the operation fails or the input is invalid.
+func (uc *PackUseCase) CalculatePacksWithOptions(
+	ctx context.Context, orderQty int, opts CalculateOptions,
+) (CalculatePacksOutput, error) {
+	ctx, span := tracer.Start(ctx, "PackUseCase.CalculatePacks", trace.WithAttributes(
+		attribute.Int("order_qty", orderQty),
+		attribute.Bool("exact_only", opts.ExactOnly),
+	))
+	defer span.End()
+
+	if err := uc.checkQuantity(orderQty); err != nil {
+		return CalculatePacksOutput{}, err
+	}
+
+	packSizes, err := uc.loadPackSizes(ctx)
+	if err != nil {
+		return CalculatePacksOutput{}, err
+	}
+
+	above, below, err := solve(ctx, orderQty, packSizes, uc.limits.MaxStates)
+	if err != nil {
+		return CalculatePacksOutput{}, err
+	}
+
+	minItems, maxItems := acceptableRange(orderQty, opts)
+	res := pickWithinRange(orderQty, minItems, maxItems, below, above)
+	if res == nil {
+		fitErr := &NoFitError{OrderQty: orderQty, MinItems: minItems, MaxItems: maxItems}
+		if above != nil {
+			fitErr.NearestAbove = above.totalItems
+		}
+		if below != nil {
+			fitErr.NearestBelow = below.totalItems
+		}
+		return CalculatePacksOutput{}, fitErr
+	}
+
+	output := buildOutput(res, packSizes, orderQty)
+	logpkg.FromContext(ctx).Debug().
+		Int("order_qty", orderQty).
+		Int("total_items", output.TotalItems).
+		Int("total_packs", output.TotalPacks).
+		Msg("calculated pack combination")
+
+	return output, nil
+}
+
+// checkQuantity rejects order quantities outside 1..Limits.MaxQuantity.
+func (uc *PackUseCase) checkQuantity(orderQty int) error {
 	if orderQty <= 0 {
-		return CalculatePacksOutput{}, errors.New("order quantity must be greater than 0")
+		return domain.ErrQuantityOutOfRange
+	}
+	if uc.limits.MaxQuantity > 0 && orderQty > uc.limits.MaxQuantity {
+		return fmt.Errorf("%w: order quantity must not exceed %d", domain.ErrQuantityOutOfRange, uc.limits.MaxQuantity)
 	}
+	return nil
+}
 
+// loadPackSizes fetches the available pack sizes from the repository.
+func (uc *PackUseCase) loadPackSizes(ctx context.Context) ([]int, error) {
+	start := time.Now()
 	packs, err := uc.packRepo.GetAllPacks(ctx)
+	getAllPacksDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
+		logpkg.FromContext(ctx).Warn().Err(err).Msg("failed to load pack sizes")
 		if errors.Is(err, domain.ErrNoPacksAvailable) {
-			return CalculatePacksOutput{}, fmt.Errorf("failed to retrieve pack sizes: %w", err)
+			return nil, fmt.Errorf("failed to retrieve pack sizes: %w", err)
 		}
 		// For other errors, we wrap with a generic message.
-		return CalculatePacksOutput{}, fmt.Errorf("use case failed to get packs: %w", err)
+		return nil, fmt.Errorf("use case failed to get packs: %w", err)
 	}
 
 	packSizes := make([]int, 0, len(packs))
 	for _, p := range packs {
 		packSizes = append(packSizes, p.Size)
 	}
+	return packSizes, nil
+}
 
-	res, err := findBestPackCombination(orderQty, packSizes)
-	if err != nil {
-		return CalculatePacksOutput{}, err
-	}
-
+// buildOutput converts a search node into the output returned to callers.
+func buildOutput(res *Node, packSizes []int, orderQty int) CalculatePacksOutput {
 	var packDetails []Pack
 	for i, count := range res.packCount {
 		if count > 0 {
@@ -66,24 +161,47 @@ func (uc *PackUseCase) CalculatePacks(ctx context.Context, orderQty int) (Calcul
 			})
 		}
 	}
-	output := CalculatePacksOutput{
+	return CalculatePacksOutput{
 		TotalItems:     res.totalItems,
-		RemainingItems: res.totalItems - orderQty,
+		RemainingItems: max(res.totalItems-orderQty, 0),
+		Difference:     res.totalItems - orderQty,
 		TotalPacks:     res.totalPacks,
 		Packs:          packDetails,
 	}
+}
 
-	return output, nil
+// solve runs findBestPackCombination inside its own span and records the search metrics.
+func solve(ctx context.Context, orderQty int, packSizes []int, maxStates int) (best, below *Node, err error) {
+	_, span := tracer.Start(ctx, "packusecase.findBestPackCombination")
+	defer span.End()
+
+	stats := searchStats{maxStates: maxStates}
+	start := time.Now()
+	best, below, err = findBestPackCombination(orderQty, packSizes, &stats)
+	stats.observe("calculate", time.Since(start).Seconds())
+
+	span.SetAttributes(
+		attribute.Int("states_explored", stats.statesExplored),
+		attribute.Int("queue_peak", stats.queuePeak),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return best, below, err
 }
 
 // findBestPackCombination finds the optimal combination of packs to fulfill the order quantity.
 // Parameters:
 //   - order: The quantity of items to fulfill in the order.
 //   - packSizes: A slice of integers representing the available pack sizes.
+//   - stats: Collects the number of explored states and the peak queue size, and holds the state budget.
 //
 // Returns:
 //   - A pointer to a Node struct representing the optimal combination of packs.
-func findBestPackCombination(order int, packSizes []int) (*Node, error) {
+//   - A pointer to a Node struct with the largest total below the order, or nil if none exists.
+//   - domain.ErrSolverBudgetExceeded if the state budget runs out first.
+func findBestPackCombination(order int, packSizes []int, stats *searchStats) (best, below *Node, err error) {
 	sort.Ints(packSizes) // Sort pack sizes in ascending order for easier index mapping.
 	maxPack := packSizes[len(packSizes)-1]
 
@@ -103,35 +221,51 @@ func findBestPackCombination(order int, packSizes []int) (*Node, error) {
 	for pq.Len() > 0 {
 		curr, ok := heap.Pop(pq).(*Node)
 		if !ok {
-			return nil, errors.New("failed to pop from priority queue")
+			return nil, nil, errors.New("failed to pop from priority queue")
+		}
+		stats.statesExplored++
+		if stats.exhausted() {
+			return nil, nil, domain.ErrSolverBudgetExceeded
 		}
 
 		// If the current state satisfies the order, it's optimal.
 		if curr.totalItems >= order {
-			return curr, nil
+			return curr, below, nil
+		}
+
+		// Nodes are popped in ascending order of totals, so the first node seen
+		// for a total is the one with the fewest packs.
+		if curr.totalItems > 0 && (below == nil || curr.totalItems > below.totalItems) {
+			below = curr
 		}
 
-		for i, size := range packSizes {
-			nextTotal := curr.totalItems + size
-			key := fmt.Sprintf("%d:%d", nextTotal, i) // Unique key for visited map.
+		pushSuccessors(pq, curr, packSizes, order+maxPack, visited)
+		stats.queuePeak = max(stats.queuePeak, pq.Len())
+	}
 
-			if nextTotal > order+maxPack || visited[key] {
-				continue
-			}
-			visited[key] = true
+	return nil, below, nil // Return nil if no combination is found
+}
 
-			newPackCount := append([]int(nil), curr.packCount...)
-			newPackCount[i]++
+// pushSuccessors pushes every state that adds one more pack to curr without exceeding limit items.
+func pushSuccessors(pq *PriorityQueue, curr *Node, packSizes []int, limit int, visited map[string]bool) {
+	for i, size := range packSizes {
+		nextTotal := curr.totalItems + size
+		key := fmt.Sprintf("%d:%d", nextTotal, i) // Unique key for visited map.
 
-			next := &Node{
-				totalItems: nextTotal,
-				totalPacks: curr.totalPacks + 1,
-				packCount:  newPackCount,
-			}
+		if nextTotal > limit || visited[key] {
+			continue
+		}
+		visited[key] = true
 
-			heap.Push(pq, next)
+		newPackCount := append([]int(nil), curr.packCount...)
+		newPackCount[i]++
+
+		next := &Node{
+			totalItems: nextTotal,
+			totalPacks: curr.totalPacks + 1,
+			packCount:  newPackCount,
 		}
-	}
 
-	return nil, nil // Return nil if no combination is found
+		heap.Push(pq, next)
+	}
 }
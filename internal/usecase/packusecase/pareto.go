@@ -0,0 +1,103 @@
+package packusecase
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"pack_optimizer/internal/domain"
+	"sort"
+	"time"
+)
+
+// ParetoFront returns every pack combination for an order that is not beaten on both
+// overage and pack count by another combination, ordered from least to most overage.
+// Packs have no cost yet, so cost is not an objective; it can join the dominance check in
+// findParetoFront once domain.Pack carries one.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - orderQty: The quantity of items to fulfill in the order.
+//   - maxPoints: The maximum number of points to return; 0 or less returns the whole front.
+//
+// Returns:
+//   - A ParetoOutput struct with the non-dominated combinations.
+//   - An error if the operation fails or the input is invalid.
+func (uc *PackUseCase) ParetoFront(ctx context.Context, orderQty, maxPoints int) (ParetoOutput, error) {
+	if err := uc.checkQuantity(orderQty); err != nil {
+		return ParetoOutput{}, err
+	}
+
+	packSizes, err := uc.loadPackSizes(ctx)
+	if err != nil {
+		return ParetoOutput{}, err
+	}
+
+	stats := searchStats{maxStates: uc.limits.MaxStates}
+	start := time.Now()
+	front, err := findParetoFront(orderQty, packSizes, &stats)
+	stats.observe("pareto", time.Since(start).Seconds())
+	if err != nil {
+		return ParetoOutput{}, err
+	}
+
+	front = samplePoints(front, maxPoints)
+	points := make([]CalculatePacksOutput, 0, len(front))
+	for _, node := range front {
+		points = append(points, buildOutput(node, packSizes, orderQty))
+	}
+
+	return ParetoOutput{Quantity: orderQty, Points: points}, nil
+}
+
+// findParetoFront explores every total from the order up to order+maxPack and keeps the
+// totals that need fewer packs than all smaller totals. No total beyond that bound can
+// join the front, since a multiple of the largest pack already reaches the minimum pack count.
+func findParetoFront(order int, packSizes []int, stats *searchStats) ([]*Node, error) {
+	sort.Ints(packSizes) // Sort pack sizes in ascending order for easier index mapping.
+	maxPack := packSizes[len(packSizes)-1]
+
+	visited := make(map[string]bool)
+
+	pq := &PriorityQueue{}
+	heap.Init(pq)
+	heap.Push(pq, &Node{packCount: make([]int, len(packSizes))})
+
+	var front []*Node
+	for pq.Len() > 0 {
+		curr, ok := heap.Pop(pq).(*Node)
+		if !ok {
+			return nil, errors.New("failed to pop from priority queue")
+		}
+		stats.statesExplored++
+		if stats.exhausted() {
+			return nil, domain.ErrSolverBudgetExceeded
+		}
+
+		// The first node popped for a total has the fewest packs for it, so later
+		// duplicates of the same total never pass this check.
+		if curr.totalItems >= order && (len(front) == 0 || curr.totalPacks < front[len(front)-1].totalPacks) {
+			front = append(front, curr)
+		}
+
+		pushSuccessors(pq, curr, packSizes, order+maxPack, visited)
+		stats.queuePeak = max(stats.queuePeak, pq.Len())
+	}
+
+	return front, nil
+}
+
+// samplePoints keeps at most limit points, always including both ends of the front
+// and spreading the rest evenly between them.
+func samplePoints(front []*Node, limit int) []*Node {
+	if limit <= 0 || len(front) <= limit {
+		return front
+	}
+	if limit == 1 {
+		return front[:1]
+	}
+
+	sampled := make([]*Node, 0, limit)
+	for i := 0; i < limit; i++ {
+		sampled = append(sampled, front[i*(len(front)-1)/(limit-1)])
+	}
+	return sampled
+}
@@ -0,0 +1,102 @@
+package packusecase
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"slices"
+	"time"
+)
+
+// maxLookupItems is the largest combination total ReverseLookup accepts when Limits.MaxQuantity
+// is not set. It matches the largest order quantity the calculate endpoint validates.
+const maxLookupItems = 99999999
+
+// ReverseLookup finds the range of order quantities whose calculated answer is exactly the given
+// pack combination under the current pack set.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - combination: The number of packs of each size, keyed by pack size.
+//
+// Returns:
+//   - A ReverseLookupOutput struct; From and To are set only when the combination is optimal.
+//   - An error wrapping domain.ErrInvalidCombination or domain.ErrUnknownPackSize for invalid input,
+//     or any other error if the operation fails.
+func (uc *PackUseCase) ReverseLookup(ctx context.Context, combination map[int]int) (ReverseLookupOutput, error) {
+	maxItems := uc.limits.MaxQuantity
+	if maxItems <= 0 {
+		maxItems = maxLookupItems
+	}
+
+	total, count := 0, 0
+	for size, n := range combination {
+		if size <= 0 || n < 0 {
+			return ReverseLookupOutput{}, fmt.Errorf(
+				"%w: pack sizes must be positive and counts must not be negative", domain.ErrInvalidCombination)
+		}
+		// Checked before multiplying so that huge counts cannot overflow.
+		if n > 0 && size > (maxItems-total)/n {
+			return ReverseLookupOutput{}, fmt.Errorf("%w: must not exceed %d items", domain.ErrInvalidCombination, maxItems)
+		}
+		total += size * n
+		count += n
+	}
+	if total == 0 {
+		return ReverseLookupOutput{}, fmt.Errorf("%w: must contain at least one pack", domain.ErrInvalidCombination)
+	}
+
+	packSizes, err := uc.loadPackSizes(ctx)
+	if err != nil {
+		return ReverseLookupOutput{}, err
+	}
+	for size, n := range combination {
+		if n > 0 && !slices.Contains(packSizes, size) {
+			return ReverseLookupOutput{}, fmt.Errorf("pack size %d: %w", size, domain.ErrUnknownPackSize)
+		}
+	}
+
+	stats := searchStats{maxStates: uc.limits.MaxStates}
+	start := time.Now()
+	best, below, err := findBestPackCombination(total, packSizes, &stats)
+	stats.observe("reverse_lookup", time.Since(start).Seconds())
+	if err != nil {
+		return ReverseLookupOutput{}, err
+	}
+
+	output := ReverseLookupOutput{TotalItems: total, TotalPacks: count}
+	optimum := buildOutput(best, packSizes, total)
+	if !sameCombination(optimum.Packs, combination) {
+		output.OptimalPacks = optimum.Packs
+		return output, nil
+	}
+
+	// Every quantity above the nearest smaller reachable total rounds up to this total.
+	output.Optimal = true
+	output.From = 1
+	if below != nil {
+		output.From = below.totalItems + 1
+	}
+	output.To = total
+
+	return output, nil
+}
+
+// sameCombination reports whether packs holds exactly the non-zero counts of combination.
+func sameCombination(packs []Pack, combination map[int]int) bool {
+	nonZero := 0
+	for _, count := range combination {
+		if count > 0 {
+			nonZero++
+		}
+	}
+	if len(packs) != nonZero {
+		return false
+	}
+
+	for _, p := range packs {
+		if combination[p.Size] != p.Count {
+			return false
+		}
+	}
+	return true
+}
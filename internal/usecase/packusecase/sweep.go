@@ -0,0 +1,129 @@
+package packusecase
+
+import (
+	"context"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"sort"
+)
+
+// Sweeper computes the optimal packs for a range of quantities in one pass over a packTable,
+// instead of solving every quantity from scratch. The table holds two int32 per total, so it
+// only grows up to Limits.MaxTable totals; larger quantities are solved one by one.
+type Sweeper struct {
+	table   *packTable
+	limits  Limits
+	from    int
+	to      int
+	step    int
+	release func() // Called once Run has computed the rows, e.g. to free an admission slot
+}
+
+// NewSweeper validates the range and loads the pack sizes, so that errors surface before
+// the caller starts streaming results.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - from: The first quantity of the range.
+//   - to: The last quantity of the range, inclusive.
+//   - step: The distance between consecutive quantities.
+//
+// Returns:
+//   - A Sweeper ready to Run.
+//   - An error if the range is invalid or the pack sizes cannot be loaded.
+func (uc *PackUseCase) NewSweeper(ctx context.Context, from, to, step int) (*Sweeper, error) {
+	if err := uc.checkQuantity(from); err != nil {
+		return nil, err
+	}
+	if to < from {
+		return nil, fmt.Errorf("%w: range end must not be less than range start", domain.ErrInvalidRange)
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("%w: step must be greater than 0", domain.ErrInvalidRange)
+	}
+	if err := uc.checkQuantity(to); err != nil {
+		return nil, err
+	}
+
+	packSizes, err := uc.loadPackSizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Ints(packSizes)
+
+	return &Sweeper{table: newPackTable(packSizes), limits: uc.limits, from: from, to: to, step: step}, nil
+}
+
+// PackSizes returns the pack sizes used by the sweep in ascending order.
+func (s *Sweeper) PackSizes() []int {
+	return s.table.sizes
+}
+
+// Run calls emit with the optimal result for every quantity in the range, in ascending order.
+// It stops at the first error returned by emit and returns it, or with the context's error
+// once ctx is done.
+func (s *Sweeper) Run(ctx context.Context, emit func(SweepRow) error) error {
+	if s.release == nil {
+		return s.run(ctx, emit)
+	}
+
+	// The slot only covers the computation. The rows are collected while it is held and emitted
+	// after it is released, so a client that reads slowly does not keep other solves waiting.
+	rows := make([]SweepRow, 0, (s.to-s.from)/s.step+1)
+	err := s.run(ctx, func(row SweepRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	s.release()
+	s.release = nil
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run computes the rows of the range and passes each one to emit as soon as it is known.
+func (s *Sweeper) run(ctx context.Context, emit func(SweepRow) error) error {
+	maxPack := s.table.sizes[len(s.table.sizes)-1]
+
+	// The smallest reachable total at or above a quantity never decreases as the
+	// quantity grows, so a single forward-moving cursor is enough.
+	next := 0
+	for qty := s.from; qty <= s.to; qty += s.step {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var best *Node
+		if s.limits.MaxTable > 0 && qty+maxPack >= s.limits.MaxTable {
+			// Past the table's cap; quantities only grow, so every remaining one is solved on its own.
+			above, _, err := solve(ctx, qty, s.table.sizes, s.limits.MaxStates)
+			if err != nil {
+				return err
+			}
+			best = above
+		} else {
+			if err := s.table.extend(ctx, qty+maxPack); err != nil {
+				return err
+			}
+			next = max(next, qty)
+			for !s.table.reachable(next) {
+				next++
+			}
+			best = s.table.node(next)
+		}
+
+		row := SweepRow{Quantity: qty, CalculatePacksOutput: buildOutput(best, s.table.sizes, qty)}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
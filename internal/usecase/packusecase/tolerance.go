@@ -0,0 +1,59 @@
+package packusecase
+
+import "math"
+
+// acceptableRange returns the inclusive range of totals the options allow for an order.
+// Without a tolerance the order may never be undershipped and any overage is accepted.
+func acceptableRange(orderQty int, opts CalculateOptions) (minItems, maxItems int) {
+	switch {
+	case opts.ExactOnly:
+		return orderQty, orderQty
+	case opts.Tolerance == nil:
+		return orderQty, math.MaxInt
+	}
+
+	under, over := opts.Tolerance.Under, opts.Tolerance.Over
+	if opts.Tolerance.Unit == TolerancePercent {
+		under = float64(orderQty) * under / 100
+		over = float64(orderQty) * over / 100
+	}
+
+	if !(under < float64(orderQty)) {
+		minItems = 0
+	} else {
+		minItems = orderQty - int(math.Floor(under))
+	}
+	// A float that does not fit in an int converts to an arbitrary value, so a window reaching
+	// past the largest int is clamped before the conversion. The negated check also catches NaN.
+	if !(over < float64(math.MaxInt-orderQty)) {
+		maxItems = math.MaxInt
+	} else {
+		maxItems = orderQty + int(math.Floor(over))
+	}
+	return minItems, maxItems
+}
+
+// pickWithinRange chooses between the nearest combinations below and above the order.
+// The one closest to the order wins, then the one with fewer packs, then the smaller shipment.
+// It returns nil if neither candidate lies inside [minItems, maxItems].
+func pickWithinRange(orderQty, minItems, maxItems int, below, above *Node) *Node {
+	if below != nil && below.totalItems < minItems {
+		below = nil
+	}
+	if above != nil && above.totalItems > maxItems {
+		above = nil
+	}
+
+	switch {
+	case below == nil:
+		return above
+	case above == nil:
+		return below
+	}
+
+	underBy, overBy := orderQty-below.totalItems, above.totalItems-orderQty
+	if overBy < underBy || (overBy == underBy && above.totalPacks < below.totalPacks) {
+		return above
+	}
+	return below
+}
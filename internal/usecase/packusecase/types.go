@@ -6,8 +6,54 @@ type Pack struct {
 }
 
 type CalculatePacksOutput struct {
+	ID             string `json:"id,omitempty"`    // ID of the recorded calculation; empty when the history is not kept
 	TotalItems     int    `json:"total_items"`     // Total items that fit in the packs
 	RemainingItems int    `json:"remaining_items"` // Number of empty spaces in packs
+	Difference     int    `json:"difference"`      // Signed difference from the ordered quantity, negative when under
 	TotalPacks     int    `json:"total_packs"`     // Total number of packs used
 	Packs          []Pack `json:"packs"`           // Calculated packs with their sizes and counts
 }
+
+// Limits bounds the work a PackUseCase accepts. Zero values mean no limit.
+type Limits struct {
+	MaxQuantity int // Largest order quantity accepted
+	MaxStates   int // States one search may explore before it fails with domain.ErrSolverBudgetExceeded
+	MaxTable    int // Totals a sweep may tabulate; quantities beyond are solved one by one
+}
+
+type CalculateOptions struct {
+	ExactOnly bool       // Only accept combinations that ship exactly the ordered quantity
+	Tolerance *Tolerance // Acceptable window around the ordered quantity, nil means never under and any overage
+}
+
+type ToleranceUnit string
+
+const (
+	ToleranceAbsolute ToleranceUnit = "absolute" // Under and Over are item counts
+	TolerancePercent  ToleranceUnit = "percent"  // Under and Over are percentages of the ordered quantity
+)
+
+type Tolerance struct {
+	Under float64       // How far below the ordered quantity a plan may fall
+	Over  float64       // How far above the ordered quantity a plan may go
+	Unit  ToleranceUnit // Unit of Under and Over, absolute when empty
+}
+
+type ParetoOutput struct {
+	Quantity int                    `json:"quantity"` // Ordered quantity the front was computed for
+	Points   []CalculatePacksOutput `json:"points"`   // Non-dominated combinations, from least overage to fewest packs
+}
+
+type SweepRow struct {
+	Quantity             int `json:"quantity"` // Ordered quantity this row was computed for
+	CalculatePacksOutput     // Optimal result for Quantity
+}
+
+type ReverseLookupOutput struct {
+	TotalItems   int    `json:"total_items"`             // Items in the requested combination
+	TotalPacks   int    `json:"total_packs"`             // Packs in the requested combination
+	Optimal      bool   `json:"optimal"`                 // Whether some order quantity is answered with exactly this combination
+	From         int    `json:"from,omitempty"`          // Smallest such order quantity
+	To           int    `json:"to,omitempty"`            // Largest such order quantity
+	OptimalPacks []Pack `json:"optimal_packs,omitempty"` // The combination calculated for TotalItems when it differs
+}
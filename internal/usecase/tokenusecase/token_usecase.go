@@ -0,0 +1,139 @@
+// Package tokenusecase provides methods for validating bearer tokens issued by the company SSO.
+package tokenusecase
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"pack_optimizer/internal/domain"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew is the leeway granted when checking exp, nbf and iat.
+const clockSkew = 30 * time.Second
+
+// signingMethods are the asymmetric algorithms accepted for tokens. HMAC is never accepted,
+// so a public key can not be used as a shared secret.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// KeyProvider returns the public key a token was signed with.
+type KeyProvider interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// Options controls which tokens are accepted and how their claims are mapped.
+type Options struct {
+	Issuer        string   // Expected iss claim; not checked when empty
+	Audience      string   // Expected aud claim; not checked when empty
+	RolesClaim    string   // Claim holding roles, dot separated for nested claims, e.g. realm_access.roles
+	TenantClaim   string   // Claim holding the tenant
+	Tenants       []string // Tenants whose tokens are accepted; not checked when empty
+	RequiredScope string   // Scope every token must grant; not checked when empty
+}
+
+// TokenUseCase is a use case that turns bearer tokens into principals.
+type TokenUseCase struct {
+	keys KeyProvider
+	opts Options
+}
+
+// NewTokenUseCase creates a new instance of TokenUseCase.
+// Parameters:
+//   - keys: The key set that holds the issuer's public keys.
+//   - opts: The expected issuer and audience and the claim mapping.
+//
+// Returns:
+//   - A pointer to a new TokenUseCase instance.
+func NewTokenUseCase(keys KeyProvider, opts Options) *TokenUseCase {
+	return &TokenUseCase{keys: keys, opts: opts}
+}
+
+// Authenticate validates a bearer token and maps its claims to a principal.
+// Parameters:
+//   - ctx: The context for managing request-scoped values, deadlines, and cancellation signals.
+//   - raw: The compact serialised JWT.
+//
+// Returns:
+//   - The principal holding the token subject, the known roles it grants and its tenant.
+//   - An error wrapping domain.ErrInvalidToken if the signature, expiry, issuer or audience is wrong,
+//     or wrapping domain.ErrForbidden if the token lacks the required scope or belongs to a tenant
+//     that is not accepted.
+func (uc *TokenUseCase) Authenticate(ctx context.Context, raw string) (domain.Principal, error) {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if uc.opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(uc.opts.Issuer))
+	}
+	if uc.opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(uc.opts.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return uc.keys.Key(ctx, kid)
+	}, parserOpts...)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: %w", domain.ErrInvalidToken, err)
+	}
+
+	if uc.opts.RequiredScope != "" && !slices.Contains(stringList(claims["scope"]), uc.opts.RequiredScope) {
+		return domain.Principal{}, fmt.Errorf("%w: token lacks scope %q", domain.ErrForbidden, uc.opts.RequiredScope)
+	}
+
+	subject, _ := claims.GetSubject()
+	principal := domain.Principal{Subject: subject, Name: subject}
+	for _, role := range stringList(lookupClaim(claims, uc.opts.RolesClaim)) {
+		if slices.Contains(domain.Roles, domain.Role(role)) && !slices.Contains(principal.Roles, domain.Role(role)) {
+			principal.Roles = append(principal.Roles, domain.Role(role))
+		}
+	}
+	if tenant, ok := lookupClaim(claims, uc.opts.TenantClaim).(string); ok {
+		principal.Tenant = tenant
+	}
+	if len(uc.opts.Tenants) > 0 && !slices.Contains(uc.opts.Tenants, principal.Tenant) {
+		return domain.Principal{}, fmt.Errorf("%w: tenant %q is not accepted", domain.ErrForbidden, principal.Tenant)
+	}
+	return principal, nil
+}
+
+// lookupClaim resolves a dot separated claim path such as realm_access.roles.
+func lookupClaim(claims jwt.MapClaims, path string) any {
+	if path == "" {
+		return nil
+	}
+	var value any = map[string]any(claims)
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[part]
+	}
+	return value
+}
+
+// stringList accepts both JSON arrays of strings and space separated strings, as used by the scope claim.
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
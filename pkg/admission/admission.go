@@ -0,0 +1,117 @@
+// Package admission bounds how many expensive operations run at once and how long callers queue for a slot.
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// ErrQueueFull is returned when the maximum number of callers is already waiting.
+	ErrQueueFull = errors.New("admission queue is full")
+	// ErrWaitTimeout is returned when no slot became free within the maximum wait time.
+	ErrWaitTimeout = errors.New("timed out waiting for a free slot")
+)
+
+var (
+	inFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pack_optimizer_admission_in_flight",
+		Help: "Number of operations holding an admission slot.",
+	})
+
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pack_optimizer_admission_queue_depth",
+		Help: "Number of operations waiting for an admission slot.",
+	})
+
+	waitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pack_optimizer_admission_wait_seconds",
+		Help:    "Time operations waited for an admission slot, including rejected ones.",
+		Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	})
+
+	rejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pack_optimizer_admission_rejected_total",
+		Help: "Number of operations rejected by admission control, by reason.",
+	}, []string{"reason"})
+)
+
+// Controller hands out a fixed number of slots. Callers beyond that wait in a bounded queue
+// for at most maxWait; everyone else is rejected immediately.
+type Controller struct {
+	slots    chan struct{}
+	maxQueue int64
+	maxWait  time.Duration
+	waiting  atomic.Int64
+}
+
+// NewController creates a Controller with maxConcurrent slots, room for maxQueue waiting callers
+// and a maximum wait of maxWait.
+func NewController(maxConcurrent, maxQueue int, maxWait time.Duration) *Controller {
+	return &Controller{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: int64(maxQueue),
+		maxWait:  maxWait,
+	}
+}
+
+// MaxWait returns the longest time a caller waits for a slot.
+func (a *Controller) MaxWait() time.Duration {
+	return a.maxWait
+}
+
+// Acquire takes a slot, waiting in the queue if none is free. The returned release function
+// must be called once the operation has finished; calling it more than once is safe.
+// It returns ErrQueueFull, ErrWaitTimeout or the context's error if no slot was taken.
+func (a *Controller) Acquire(ctx context.Context) (release func(), err error) {
+	start := time.Now()
+	defer func() { waitDuration.Observe(time.Since(start).Seconds()) }()
+
+	select {
+	case a.slots <- struct{}{}:
+		return a.granted(), nil
+	default:
+	}
+
+	if a.waiting.Add(1) > a.maxQueue {
+		a.waiting.Add(-1)
+		rejectedTotal.WithLabelValues("queue_full").Inc()
+		return nil, ErrQueueFull
+	}
+	queueDepth.Inc()
+	defer func() {
+		a.waiting.Add(-1)
+		queueDepth.Dec()
+	}()
+
+	timer := time.NewTimer(a.maxWait)
+	defer timer.Stop()
+
+	select {
+	case a.slots <- struct{}{}:
+		return a.granted(), nil
+	case <-timer.C:
+		rejectedTotal.WithLabelValues("timeout").Inc()
+		return nil, ErrWaitTimeout
+	case <-ctx.Done():
+		rejectedTotal.WithLabelValues("canceled").Inc()
+		return nil, ctx.Err()
+	}
+}
+
+func (a *Controller) granted() func() {
+	inFlight.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-a.slots
+			inFlight.Dec()
+		})
+	}
+}
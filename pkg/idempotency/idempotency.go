@@ -0,0 +1,94 @@
+// Package idempotency stores responses by idempotency key so that retried requests can be replayed.
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of started requests between scans that drop expired records.
+const sweepEvery = 1024
+
+// Response is a stored HTTP response.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Record is the state kept for one idempotency key.
+type Record struct {
+	RequestHash string    // Fingerprint of the request that first used the key
+	Response    *Response // nil while that request is still being processed
+	ExpiresAt   time.Time
+}
+
+// Store keeps records until they expire. Implementations must make Begin atomic per key,
+// so that two concurrent requests with the same key can not both start.
+type Store interface {
+	// Begin reserves key for a request with the given hash. When the key is already in use,
+	// started is false and the existing record is returned instead.
+	Begin(ctx context.Context, key, requestHash string, ttl time.Duration) (existing Record, started bool, err error)
+	// Complete stores the response of the request that reserved key.
+	Complete(ctx context.Context, key string, resp Response) error
+	// Abort releases a reservation, so that the request can be retried.
+	Abort(ctx context.Context, key string) error
+}
+
+// MemoryStore keeps records in process memory. It is the default store and only deduplicates
+// retries that reach the same instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	started int
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record), now: time.Now}
+}
+
+// Begin implements Store.
+func (s *MemoryStore) Begin(_ context.Context, key, requestHash string, ttl time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if rec, ok := s.records[key]; ok && now.Before(rec.ExpiresAt) {
+		return rec, false, nil
+	}
+
+	s.started++
+	if s.started%sweepEvery == 0 {
+		for k, rec := range s.records {
+			if !now.Before(rec.ExpiresAt) {
+				delete(s.records, k)
+			}
+		}
+	}
+	s.records[key] = Record{RequestHash: requestHash, ExpiresAt: now.Add(ttl)}
+	return Record{}, true, nil
+}
+
+// Complete implements Store.
+func (s *MemoryStore) Complete(_ context.Context, key string, resp Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok {
+		rec.Response = &resp
+		s.records[key] = rec
+	}
+	return nil
+}
+
+// Abort implements Store.
+func (s *MemoryStore) Abort(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
@@ -2,6 +2,9 @@
 package logpkg
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -9,10 +12,43 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// InitLogger is a global logger
-func InitLogger() {
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stderr,
-		TimeFormat: time.RFC3339,
-	}).With().Timestamp().Logger()
+// Supported output formats.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// InitLogger configures the global logger with the given level (e.g. "debug", "info")
+// and format ("json" for production, "console" for human-readable output).
+func InitLogger(level, format string) error {
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	var out io.Writer
+	switch format {
+	case FormatJSON:
+		out = os.Stderr
+	case FormatConsole:
+		out = zerolog.ConsoleWriter{
+			Out:        os.Stderr,
+			TimeFormat: time.RFC3339,
+		}
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+
+	zerolog.SetGlobalLevel(lvl)
+	log.Logger = zerolog.New(out).With().Timestamp().Logger()
+	return nil
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the global logger
+// when the context does not carry one.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log.Logger
 }
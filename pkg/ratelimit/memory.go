@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of Take calls between scans that drop idle buckets.
+const sweepEvery = 1024
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // When the bucket will be full again and can be forgotten
+}
+
+// MemoryStore keeps buckets in process memory. It is the default store and only limits
+// the instance it runs in.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, charges ...Charge) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	buckets := make([]*bucket, len(charges))
+	states := make([]State, len(charges))
+	for i, charge := range charges {
+		b, ok := s.buckets[charge.Key]
+		if !ok {
+			b = &bucket{tokens: charge.Limit.Burst, last: now}
+			s.buckets[charge.Key] = b
+		}
+		buckets[i], states[i] = b, State{Tokens: b.tokens, Last: b.last}
+	}
+
+	next, results := TakeAll(states, now, charges)
+	for i, b := range buckets {
+		b.tokens, b.last, b.full = next[i].Tokens, next[i].Last, now.Add(results[i].Reset)
+	}
+	return results, nil
+}
+
+// sweep drops buckets that have refilled completely; they are recreated full on demand.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
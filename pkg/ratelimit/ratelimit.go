@@ -0,0 +1,110 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable bucket storage.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket that holds at most Burst tokens and refills at Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst float64
+}
+
+// Result is the outcome of taking tokens from a bucket.
+type Result struct {
+	Allowed    bool
+	Remaining  float64       // Tokens left after the request
+	RetryAfter time.Duration // When a rejected request can be retried; zero when allowed
+	Reset      time.Duration // How long until the bucket is full again
+}
+
+// Charge is the number of tokens a request takes from the bucket stored under Key.
+type Charge struct {
+	Key   string
+	Cost  float64
+	Limit Limit
+}
+
+// State is the stored state of one bucket: it held Tokens at Last.
+type State struct {
+	Tokens float64
+	Last   time.Time
+}
+
+// Store holds the state of token buckets. Take returns one Result per charge and takes the
+// tokens only if every bucket allows its charge, so a request rejected by one bucket is not
+// charged to the others. Implementations must apply Take atomically across its keys, so that
+// several server instances sharing a store enforce one limit.
+type Store interface {
+	Take(ctx context.Context, charges ...Charge) ([]Result, error)
+}
+
+// TakeAll applies the charges to the buckets in states, which must be in the same order.
+// It returns the new state of every bucket and one result per charge; if any charge is
+// rejected, nothing is taken and the results of the other buckets report their tokens
+// untouched. Stores use it to share one refill rule.
+func TakeAll(states []State, now time.Time, charges []Charge) ([]State, []Result) {
+	next := make([]State, len(charges))
+	results := make([]Result, len(charges))
+	allowed := true
+	for i, charge := range charges {
+		var tokens float64
+		tokens, results[i] = Take(states[i].Tokens, states[i].Last, now, charge.Cost, charge.Limit)
+		next[i] = State{Tokens: tokens, Last: now}
+		allowed = allowed && results[i].Allowed
+	}
+	if allowed {
+		return next, results
+	}
+	for i, charge := range charges {
+		if results[i].Allowed {
+			next[i].Tokens, results[i] = Take(states[i].Tokens, states[i].Last, now, 0, charge.Limit)
+		}
+	}
+	return next, results
+}
+
+// Take refills a bucket that held tokens at last and, if enough tokens are available at now,
+// removes cost of them. It returns the new token count and the result. A cost above the
+// burst is never allowed, since the bucket can not hold that many tokens; callers should
+// reject such requests before they reach the store.
+func Take(tokens float64, last, now time.Time, cost float64, limit Limit) (float64, Result) {
+	elapsed := now.Sub(last).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(limit.Burst, tokens+elapsed*limit.Rate)
+	}
+
+	if cost > limit.Burst {
+		return tokens, Result{
+			Allowed:   false,
+			Remaining: tokens,
+			Reset:     limit.duration(limit.Burst - tokens),
+		}
+	}
+	if tokens < cost {
+		return tokens, Result{
+			Allowed:    false,
+			Remaining:  tokens,
+			RetryAfter: limit.duration(cost - tokens),
+			Reset:      limit.duration(limit.Burst - tokens),
+		}
+	}
+
+	tokens -= cost
+	return tokens, Result{
+		Allowed:   true,
+		Remaining: tokens,
+		Reset:     limit.duration(limit.Burst - tokens),
+	}
+}
+
+// duration returns how long the bucket needs to refill the given number of tokens.
+func (l Limit) duration(tokens float64) time.Duration {
+	if l.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
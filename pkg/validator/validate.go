@@ -1,6 +1,30 @@
 // Package validator provides validation utilities using go-playground/validator.
 package validator
 
-import "github.com/go-playground/validator/v10"
+import (
+	"reflect"
+	"strings"
 
-var Validate = validator.New()
+	"github.com/go-playground/validator/v10"
+)
+
+var Validate = newValidate()
+
+// newValidate reports fields by the name clients send (their json or query tag)
+// instead of the Go field name.
+func newValidate() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	return v
+}
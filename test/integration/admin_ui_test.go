@@ -0,0 +1,212 @@
+package integration
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/adminhandler"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+	"pack_optimizer/internal/usecase/packsetusecase"
+	"pack_optimizer/templates"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var csrfTokenPattern = regexp.MustCompile(`name="_csrf" value="([^"]+)"`)
+
+// browserSession signs in to the server-rendered pages like a browser: with Basic credentials and the CSRF cookie.
+type browserSession struct {
+	t     *testing.T
+	app   *fiber.App
+	key   string
+	token string
+}
+
+func (s *browserSession) do(method, target string, form url.Values) (*http.Response, string) {
+	s.t.Helper()
+	var body io.Reader
+	if form != nil {
+		form.Set(middlewares.CSRFField, s.token)
+		body = strings.NewReader(form.Encode())
+	}
+	req := httptest.NewRequest(method, target, body)
+	if form != nil {
+		req.Header.Set("Content-Type", fiber.MIMEApplicationForm)
+	}
+	if s.key != "" {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:"+s.key)))
+	}
+	if s.token != "" {
+		req.AddCookie(&http.Cookie{Name: "csrf_", Value: s.token})
+	}
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.t, err)
+	page, _ := io.ReadAll(resp.Body)
+	if match := csrfTokenPattern.FindSubmatch(page); match != nil {
+		s.token = string(match[1])
+	}
+	return resp, string(page)
+}
+
+// newAdminTestApp serves the admin pages over a SQLite database holding sizes.
+func newAdminTestApp(t *testing.T, sizes ...int) (*fiber.App, domain.PackSetRepository, *apikeyusecase.APIKeyUseCase) {
+	gormDB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.AutoMigrate(&domain.Pack{}, &domain.APIKey{}))
+	repo := sqlrepo.NewPackRepo(gormDB)
+	require.NoError(t, repo.UpdatePacks(t.Context(), sizes, nil))
+	keys := apikeyusecase.NewAPIKeyUseCase(sqlrepo.NewAPIKeyRepo(gormDB))
+
+	handler := adminhandler.NewAdminHandler(packsetusecase.NewPackSetUseCase(repo, packsetusecase.WithMaxPackSize(100000)))
+	app := fiber.New(fiber.Config{
+		ErrorHandler: customerrrors.ErrorHandler,
+		Views:        html.NewFileSystem(http.FS(templates.FS), ".html"),
+	})
+	admin := app.Group("/admin",
+		middlewares.BasicAuthMiddleware(keys, "test"),
+		middlewares.RequireRole(false, domain.RolePackAdmin),
+		middlewares.CSRFMiddleware("/admin"),
+	)
+	admin.Get("/packs", handler.ListPacks)
+	admin.Post("/packs", handler.AddPack)
+	admin.Get("/packs/:size/edit", handler.EditPack)
+	admin.Post("/packs/:size/edit", handler.UpdatePack)
+	admin.Post("/packs/:size/delete", handler.RemovePack)
+	return app, repo, keys
+}
+
+func storedSizes(t *testing.T, repo domain.PackRepository) []int {
+	t.Helper()
+	packs, err := repo.GetAllPacks(t.Context())
+	require.NoError(t, err)
+	sizes := make([]int, len(packs))
+	for i, pack := range packs {
+		sizes[i] = pack.Size
+	}
+	return sizes
+}
+
+func TestAdminUI_RequiresPackAdmin(t *testing.T) {
+	app, _, keys := newAdminTestApp(t, 250)
+	calculator, err := keys.Issue(t.Context(), "erp", domain.RoleCalculator)
+	require.NoError(t, err)
+
+	resp, _ := (&browserSession{t: t, app: app}).do("GET", "/admin/packs", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `Basic realm="test"`)
+
+	resp, _ = (&browserSession{t: t, app: app, key: "po_nope"}).do("GET", "/admin/packs", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = (&browserSession{t: t, app: app, key: calculator.Key}).do("GET", "/admin/packs", nil)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestAdminUI_ManagesPackSizes(t *testing.T) {
+	app, repo, keys := newAdminTestApp(t, 250, 500)
+	admin, err := keys.Issue(t.Context(), "ops", domain.RolePackAdmin)
+	require.NoError(t, err)
+	session := &browserSession{t: t, app: app, key: admin.Key}
+
+	resp, page := session.do("GET", "/admin/packs", nil)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, page, "250 items")
+	assert.Contains(t, page, "Signed in as ops")
+	require.NotEmpty(t, session.token)
+
+	t.Run("RejectsFormsWithoutCSRFToken", func(t *testing.T) {
+		forged := &browserSession{t: t, app: app, key: admin.Key}
+		resp, _ := forged.do("POST", "/admin/packs", url.Values{"size": {"750"}})
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, []int{250, 500}, storedSizes(t, repo))
+	})
+
+	t.Run("ShowsValidationErrorsInline", func(t *testing.T) {
+		resp, page := session.do("POST", "/admin/packs", url.Values{"size": {"abc"}})
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Contains(t, page, "Pack size must be a whole number.")
+		assert.Contains(t, page, `value="abc"`)
+
+		resp, page = session.do("POST", "/admin/packs", url.Values{"size": {"500"}})
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Contains(t, page, "Pack size 500 already exists.")
+
+		resp, page = session.do("POST", "/admin/packs", url.Values{"size": {"1000000000"}})
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Contains(t, page, "Pack size 1000000000 must not exceed 100000.")
+		assert.Equal(t, []int{250, 500}, storedSizes(t, repo))
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		resp, _ := session.do("POST", "/admin/packs", url.Values{"size": {"750"}})
+		assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+		assert.Equal(t, "/admin/packs?done=added&size=750", resp.Header.Get("Location"))
+		assert.Equal(t, []int{250, 500, 750}, storedSizes(t, repo))
+
+		_, page := session.do("GET", "/admin/packs?done=added&size=750", nil)
+		assert.Contains(t, page, "Pack size 750 was added.")
+	})
+
+	t.Run("EditAfterConfirmation", func(t *testing.T) {
+		resp, page := session.do("POST", "/admin/packs/500/edit", url.Values{"size": {"600"}})
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Contains(t, page, "Change pack size 500 to 600?")
+		assert.Equal(t, []int{250, 500, 750}, storedSizes(t, repo), "nothing changes before confirmation")
+
+		resp, _ = session.do("POST", "/admin/packs/500/edit", url.Values{"size": {"600"}, "confirm": {"true"}})
+		assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+		assert.Equal(t, []int{250, 600, 750}, storedSizes(t, repo))
+
+		resp, page = session.do("POST", "/admin/packs/600/edit", url.Values{"size": {"-1"}})
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Contains(t, page, "Pack size -1 must be greater than 0.")
+
+		resp, page = session.do("POST", "/admin/packs/600/edit", url.Values{"size": {"100001"}, "confirm": {"true"}})
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Contains(t, page, "Pack size 100001 must not exceed 100000.")
+		assert.Equal(t, []int{250, 600, 750}, storedSizes(t, repo))
+	})
+
+	t.Run("RemoveAfterConfirmation", func(t *testing.T) {
+		resp, page := session.do("POST", "/admin/packs/750/delete", url.Values{})
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Contains(t, page, "Remove pack size 750?")
+		assert.Equal(t, []int{250, 600, 750}, storedSizes(t, repo))
+
+		resp, _ = session.do("POST", "/admin/packs/750/delete", url.Values{"confirm": {"true"}})
+		assert.Equal(t, fiber.StatusSeeOther, resp.StatusCode)
+		assert.Equal(t, []int{250, 600}, storedSizes(t, repo))
+	})
+
+	t.Run("UnknownSize", func(t *testing.T) {
+		resp, _ := session.do("GET", "/admin/packs/999/edit", nil)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestAdminUI_KeepsTheLastPackSize(t *testing.T) {
+	app, repo, keys := newAdminTestApp(t, 250)
+	admin, err := keys.Issue(t.Context(), "ops", domain.RolePackAdmin)
+	require.NoError(t, err)
+	session := &browserSession{t: t, app: app, key: admin.Key}
+	session.do("GET", "/admin/packs", nil)
+
+	resp, page := session.do("POST", "/admin/packs/250/delete", url.Values{"confirm": {"true"}})
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Contains(t, page, "At least one pack size is required.")
+	assert.Equal(t, []int{250}, storedSizes(t, repo))
+}
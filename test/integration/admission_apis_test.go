@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/packhandler"
+	"pack_optimizer/internal/usecase/packusecase"
+	"pack_optimizer/pkg/admission"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticPackRepo struct{}
+
+func (staticPackRepo) GetAllPacks(context.Context) ([]domain.Pack, error) {
+	return []domain.Pack{{Size: 250}, {Size: 500}}, nil
+}
+
+// TestAdmissionApi checks that a saturated solver answers with 503 and Retry-After.
+func TestAdmissionApi(t *testing.T) {
+	controller := admission.NewController(1, 0, 1500*time.Millisecond)
+	uc := packusecase.NewAdmittedPackUseCase(packusecase.NewPackUseCase(staticPackRepo{}), controller)
+
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+	app.Post("/api/v1/packs/calculate", packhandler.NewPackHandler(uc).CalculatePacks)
+
+	calculate := func() (int, string, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/api/v1/packs/calculate", bytes.NewBufferString(`{"quantity": 251}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, resp.Header.Get("Retry-After"), body
+	}
+
+	release, err := controller.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	status, retryAfter, body := calculate()
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Equal(t, "2", retryAfter)
+	assert.Equal(t, "SERVER_OVERLOADED", body["code"])
+	assert.Equal(t, "server is at capacity, retry later: admission queue is full", body["detail"])
+
+	release()
+	status, _, body = calculate()
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, float64(500), body["total_items"])
+}
@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+	"pack_optimizer/internal/usecase/tokenusecase"
+	"pack_optimizer/pkg/jwks"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestAPIKeyAuth checks API key authentication and role enforcement against keys stored in SQLite.
+func TestAPIKeyAuth(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open("file:apikeys?mode=memory&cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.AutoMigrate(&domain.APIKey{}))
+
+	uc := apikeyusecase.NewAPIKeyUseCase(sqlrepo.NewAPIKeyRepo(gormDB))
+	calculator, err := uc.Issue(context.Background(), "erp", domain.RoleCalculator)
+	assert.NoError(t, err)
+	auditor, err := uc.Issue(context.Background(), "audit", domain.RoleAuditor)
+	assert.NoError(t, err)
+	revoked, err := uc.Issue(context.Background(), "old", domain.RoleCalculator)
+	assert.NoError(t, err)
+	assert.NoError(t, uc.Revoke(context.Background(), revoked.ID))
+
+	newApp := func(allowAnonymous bool) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+		api := app.Group("/api", middlewares.AuthMiddleware(uc, nil))
+		api.Post("/calculate", middlewares.RequireRole(allowAnonymous, domain.RoleCalculator), func(c *fiber.Ctx) error {
+			principal, _ := middlewares.PrincipalFromCtx(c)
+			return c.JSON(fiber.Map{"subject": principal.Subject, "name": principal.Name})
+		})
+		return app
+	}
+
+	tests := []struct {
+		name            string
+		allowAnonymous  bool
+		key             string
+		expectedStatus  int
+		expectedCode    string
+		expectedSubject string
+		expectedName    string
+	}{
+		{name: "ValidKey", key: calculator.Key, expectedStatus: fiber.StatusOK, expectedSubject: strconv.FormatUint(uint64(calculator.ID), 10), expectedName: "erp"},
+		{name: "Anonymous_Allowed", allowAnonymous: true, expectedStatus: fiber.StatusOK},
+		{name: "Anonymous_Rejected", expectedStatus: fiber.StatusUnauthorized, expectedCode: "UNAUTHORIZED"},
+		{name: "UnknownKey", allowAnonymous: true, key: "po_nope", expectedStatus: fiber.StatusUnauthorized, expectedCode: "UNAUTHORIZED"},
+		{name: "RevokedKey", key: revoked.Key, expectedStatus: fiber.StatusUnauthorized, expectedCode: "UNAUTHORIZED"},
+		{name: "WrongRole", key: auditor.Key, expectedStatus: fiber.StatusForbidden, expectedCode: "FORBIDDEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/calculate", nil)
+			if tt.key != "" {
+				req.Header.Set(middlewares.APIKeyHeader, tt.key)
+			}
+
+			resp, err := newApp(tt.allowAnonymous).Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			var body map[string]interface{}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, body["code"])
+				assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), customerrrors.ProblemContentType))
+				return
+			}
+			assert.Equal(t, tt.expectedSubject, body["subject"])
+			assert.Equal(t, tt.expectedName, body["name"])
+		})
+	}
+}
+
+// TestBearerTokenAuth checks bearer token validation against a local key set written to a file.
+func TestBearerTokenAuth(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	// Publish only signingKey in the key set.
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	doc, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kid": "test-key",
+		"kty": "RSA",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(signingKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signingKey.E)).Bytes()),
+	}}})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(jwksPath, doc, 0o600))
+
+	keySet, err := jwks.Load(context.Background(), jwksPath, time.Hour)
+	assert.NoError(t, err)
+	tokens := tokenusecase.NewTokenUseCase(keySet, tokenusecase.Options{
+		Issuer:        "https://sso.example.com",
+		Audience:      "pack_optimizer",
+		RolesClaim:    "realm_access.roles",
+		TenantClaim:   "tenant",
+		Tenants:       []string{"acme", "globex"},
+		RequiredScope: "packs",
+	})
+
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+	app.Use(middlewares.AuthMiddleware(nil, tokens))
+	app.Post("/calculate", middlewares.RequireRole(false, domain.RoleCalculator), func(c *fiber.Ctx) error {
+		principal, _ := middlewares.PrincipalFromCtx(c)
+		return c.JSON(principal)
+	})
+
+	sign := func(key *rsa.PrivateKey, overrides jwt.MapClaims) string {
+		claims := jwt.MapClaims{
+			"iss":          "https://sso.example.com",
+			"aud":          "pack_optimizer",
+			"sub":          "alice",
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"scope":        "openid packs",
+			"tenant":       "acme",
+			"realm_access": map[string]interface{}{"roles": []string{"calculator", "offline_access"}},
+		}
+		for k, v := range overrides {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test-key"
+		signed, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return signed
+	}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedCode   string
+		expectedAuth   string
+	}{
+		{name: "ValidToken", authorization: "Bearer " + sign(signingKey, nil), expectedStatus: fiber.StatusOK},
+		{
+			name:           "Expired",
+			authorization:  "Bearer " + sign(signingKey, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+			expectedAuth:   `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "WrongAudience",
+			authorization:  "Bearer " + sign(signingKey, jwt.MapClaims{"aud": "billing"}),
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+			expectedAuth:   `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "UnknownSigningKey",
+			authorization:  "Bearer " + sign(otherKey, nil),
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+			expectedAuth:   `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "MissingScope",
+			authorization:  "Bearer " + sign(signingKey, jwt.MapClaims{"scope": "openid"}),
+			expectedStatus: fiber.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+			expectedAuth:   `Bearer error="insufficient_scope"`,
+		},
+		{
+			name:           "OtherTenant",
+			authorization:  "Bearer " + sign(signingKey, jwt.MapClaims{"tenant": "initech"}),
+			expectedStatus: fiber.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+		},
+		{
+			name:           "MissingTenant",
+			authorization:  "Bearer " + sign(signingKey, jwt.MapClaims{"tenant": nil}),
+			expectedStatus: fiber.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+		},
+		{
+			name: "MissingRole",
+			authorization: "Bearer " + sign(signingKey, jwt.MapClaims{
+				"realm_access": map[string]interface{}{"roles": []string{"auditor"}},
+			}),
+			expectedStatus: fiber.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+		},
+		{name: "Malformed", authorization: "Bearer not-a-jwt", expectedStatus: fiber.StatusUnauthorized, expectedCode: "UNAUTHORIZED"},
+		{name: "NoToken", expectedStatus: fiber.StatusUnauthorized, expectedCode: "UNAUTHORIZED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/calculate", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedAuth != "" {
+				assert.Equal(t, tt.expectedAuth, resp.Header.Get("WWW-Authenticate"))
+			}
+
+			if tt.expectedCode != "" {
+				var body map[string]interface{}
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tt.expectedCode, body["code"])
+				return
+			}
+
+			var principal domain.Principal
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&principal))
+			assert.Equal(t, domain.Principal{Subject: "alice", Name: "alice", Roles: []domain.Role{domain.RoleCalculator}, Tenant: "acme"}, principal)
+		})
+	}
+}
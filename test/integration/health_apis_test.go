@@ -0,0 +1,174 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"pack_optimizer/internal/handler/healthhandler"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestHealthApis checks the /healthz and /readyz endpoints with stubbed dependency checks.
+func TestHealthApis(t *testing.T) {
+	okCheck := healthhandler.Check{Name: "database", Run: func(context.Context) error { return nil }}
+	failingCheck := healthhandler.Check{Name: "packs", Run: func(context.Context) error {
+		return errors.New("no packs available")
+	}}
+
+	tests := []struct {
+		name           string
+		path           string
+		shuttingDown   bool
+		checks         []healthhandler.Check
+		expectedStatus int
+		expectedBody   healthhandler.Report
+	}{
+		{
+			name:           "Liveness_IgnoresChecks",
+			path:           "/healthz",
+			checks:         []healthhandler.Check{failingCheck},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   healthhandler.Report{Status: "ok"},
+		},
+		{
+			name:           "Readiness_AllOk",
+			path:           "/readyz",
+			checks:         []healthhandler.Check{okCheck},
+			expectedStatus: fiber.StatusOK,
+			expectedBody: healthhandler.Report{Status: "ready", Components: map[string]healthhandler.ComponentStatus{
+				"database": {Status: "ok"},
+			}},
+		},
+		{
+			name:           "Readiness_FailingCheck",
+			path:           "/readyz",
+			checks:         []healthhandler.Check{okCheck, failingCheck},
+			expectedStatus: fiber.StatusServiceUnavailable,
+			expectedBody: healthhandler.Report{Status: "not_ready", Components: map[string]healthhandler.ComponentStatus{
+				"database": {Status: "ok"},
+				"packs":    {Status: "fail", Error: "no packs available"},
+			}},
+		},
+		{
+			name: "Readiness_Details",
+			path: "/readyz",
+			checks: []healthhandler.Check{{
+				Name:    "database",
+				Run:     func(context.Context) error { return nil },
+				Details: func() map[string]any { return map[string]any{"in_use": 2} },
+			}},
+			expectedStatus: fiber.StatusOK,
+			expectedBody: healthhandler.Report{Status: "ready", Components: map[string]healthhandler.ComponentStatus{
+				"database": {Status: "ok", Details: map[string]any{"in_use": float64(2)}},
+			}},
+		},
+		{
+			name:           "Readiness_ShuttingDown",
+			path:           "/readyz",
+			shuttingDown:   true,
+			checks:         []healthhandler.Check{okCheck},
+			expectedStatus: fiber.StatusServiceUnavailable,
+			expectedBody: healthhandler.Report{Status: "not_ready", Components: map[string]healthhandler.ComponentStatus{
+				"database": {Status: "ok"},
+				"shutdown": {Status: "fail", Error: "server is shutting down"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shuttingDown := func() bool { return tt.shuttingDown }
+			healthHandler := healthhandler.NewHealthHandler(shuttingDown, tt.checks...)
+
+			app := fiber.New()
+			app.Get("/healthz", healthHandler.Liveness)
+			app.Get("/readyz", healthHandler.Readiness)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil), -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			var report healthhandler.Report
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			assert.Equal(t, tt.expectedBody, report)
+		})
+	}
+}
+
+// TestReadiness_Migrations checks the migrations readiness check against a real SQLite database.
+func TestReadiness_Migrations(t *testing.T) {
+	latest, err := db.LatestVersion(db.DriverSQLite)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		prepare        func(t *testing.T, migrator *db.Migrator, gormDB *gorm.DB)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "Latest",
+			prepare:        func(t *testing.T, migrator *db.Migrator, _ *gorm.DB) { require.NoError(t, migrator.Up()) },
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name: "Dirty",
+			prepare: func(t *testing.T, migrator *db.Migrator, gormDB *gorm.DB) {
+				require.NoError(t, migrator.Up())
+				// What golang-migrate leaves behind when a migration fails halfway.
+				require.NoError(t, gormDB.Exec("UPDATE schema_migrations SET dirty = true").Error)
+			},
+			expectedStatus: fiber.StatusServiceUnavailable,
+			expectedError:  fmt.Sprintf("migration %d is dirty", latest),
+		},
+		{
+			name: "OldVersion",
+			prepare: func(t *testing.T, migrator *db.Migrator, _ *gorm.DB) {
+				require.NoError(t, migrator.Goto(latest-1))
+			},
+			expectedStatus: fiber.StatusServiceUnavailable,
+			expectedError:  fmt.Sprintf("database is at version %d, latest is %d", latest-1, latest),
+		},
+		{
+			name:           "NeverMigrated",
+			prepare:        func(*testing.T, *db.Migrator, *gorm.DB) {},
+			expectedStatus: fiber.StatusServiceUnavailable,
+			expectedError:  fmt.Sprintf("database is at version 0, latest is %d", latest),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := configs.DB{Driver: db.DriverSQLite, GormDSN: filepath.Join(t.TempDir(), "packs.db")}
+			gormDB, err := db.Connect(t.Context(), cfg)
+			require.NoError(t, err)
+			migrator, err := db.NewMigrator(cfg, gormDB)
+			require.NoError(t, err)
+			tt.prepare(t, migrator, gormDB)
+
+			healthHandler := healthhandler.NewHealthHandler(func() bool { return false }, healthhandler.Check{
+				Name: "migrations",
+				Run:  func(ctx context.Context) error { return db.CheckVersion(ctx, cfg.Driver, gormDB) },
+			})
+			app := fiber.New()
+			app.Get("/readyz", healthHandler.Readiness)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			var report healthhandler.Report
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			assert.Equal(t, tt.expectedError, report.Components["migrations"].Error)
+		})
+	}
+}
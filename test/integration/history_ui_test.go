@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/historyhandler"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/handler/packhandler"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+	"pack_optimizer/internal/usecase/historyusecase"
+	"pack_optimizer/internal/usecase/packusecase"
+	"pack_optimizer/templates"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newHistoryTestApp serves the calculate endpoint, recording its results, and the history pages
+// over a SQLite database holding sizes.
+func newHistoryTestApp(t *testing.T, sizes ...int) (*fiber.App, *apikeyusecase.APIKeyUseCase) {
+	gormDB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.AutoMigrate(&domain.Pack{}, &domain.APIKey{}, &domain.Calculation{}))
+	packRepo := sqlrepo.NewPackRepo(gormDB)
+	require.NoError(t, packRepo.UpdatePacks(t.Context(), sizes, nil))
+	keys := apikeyusecase.NewAPIKeyUseCase(sqlrepo.NewAPIKeyRepo(gormDB))
+
+	history := historyusecase.NewHistoryUseCase(sqlrepo.NewCalculationRepo(gormDB))
+	packHandler := packhandler.NewPackHandler(historyusecase.NewRecordingPackUseCase(packusecase.NewPackUseCase(packRepo), history))
+	historyHandler := historyhandler.NewHistoryHandler(history)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: customerrrors.ErrorHandler,
+		Views:        html.NewFileSystem(http.FS(templates.FS), ".html"),
+	})
+	app.Post("/api/v1/packs/calculate", packHandler.CalculatePacks)
+	pages := app.Group("/history", middlewares.BasicAuthMiddleware(keys, "test"))
+	pages.Get("/", middlewares.RequireRole(false, domain.RoleAuditor, domain.RolePackAdmin), historyHandler.List)
+	pages.Get("/:id", middlewares.RequireRole(false, domain.RoleCalculator, domain.RolePackAdmin, domain.RoleAuditor), historyHandler.Show)
+	return app, keys
+}
+
+// calculate runs a calculation through the API and returns the ID it was recorded under.
+func calculate(t *testing.T, app *fiber.App, body string) string {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/v1/packs/calculate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var output packusecase.CalculatePacksOutput
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&output))
+	require.NotEmpty(t, output.ID)
+	return output.ID
+}
+
+func TestHistoryUI_SharesACalculation(t *testing.T) {
+	app, keys := newHistoryTestApp(t, 250, 500, 1000)
+	calculator, err := keys.Issue(t.Context(), "sales", domain.RoleCalculator)
+	require.NoError(t, err)
+	id := calculate(t, app, `{"quantity": 501}`)
+
+	session := &browserSession{t: t, app: app, key: calculator.Key}
+	resp, page := session.do("GET", "/history/"+id, nil)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, page, "501 items ordered")
+	assert.Contains(t, page, "<svg")
+	assert.Contains(t, page, "1 × 500-item packs = 500 items")
+	assert.Contains(t, page, "249 items of overage")
+	assert.Contains(t, page, `value="http://example.com/history/`+id+`"`)
+
+	assert.NotContains(t, page, "under and")
+
+	tolerated := calculate(t, app, `{"quantity": 501, "tolerance": {"under": 1, "over": 2.5, "unit": "percent"}}`)
+	_, page = session.do("GET", "/history/"+tolerated, nil)
+	assert.Contains(t, page, "up to 1% under and 2.5% over")
+	exact := calculate(t, app, `{"quantity": 500, "exact_only": true}`)
+	_, page = session.do("GET", "/history/"+exact, nil)
+	assert.Contains(t, page, "exact quantity only")
+
+	resp, _ = session.do("GET", "/history/5f0c1c51-52a1-4d5c-9a55-0d8ab5cf0f36", nil)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, _ = (&browserSession{t: t, app: app}).do("GET", "/history/"+id, nil)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = session.do("GET", "/history", nil)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, "only auditors and pack admins see the whole history")
+}
+
+func TestHistoryUI_ListsAndFilters(t *testing.T) {
+	app, keys := newHistoryTestApp(t, 250, 500, 1000)
+	auditor, err := keys.Issue(t.Context(), "audit", domain.RoleAuditor)
+	require.NoError(t, err)
+	exact := calculate(t, app, `{"quantity": 250}`)
+	over := calculate(t, app, `{"quantity": 501}`)
+	large := calculate(t, app, `{"quantity": 1000}`)
+	session := &browserSession{t: t, app: app, key: auditor.Key}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []string
+		expectedBody   string
+	}{
+		{name: "All", expectedStatus: fiber.StatusOK, expectedIDs: []string{exact, over, large}},
+		{name: "Outcome", query: "?outcome=over", expectedStatus: fiber.StatusOK, expectedIDs: []string{over}},
+		{name: "QuantityRange", query: "?min_quantity=300&max_quantity=1000", expectedStatus: fiber.StatusOK, expectedIDs: []string{over, large}},
+		{name: "EmptyFiltersAreIgnored", query: "?from=&to=&min_quantity=&outcome=", expectedStatus: fiber.StatusOK, expectedIDs: []string{exact, over, large}},
+		{name: "NoMatches", query: "?from=2999-01-01", expectedStatus: fiber.StatusOK, expectedBody: "No calculations match these filters."},
+		{name: "ReversedRange", query: "?min_quantity=600&max_quantity=100", expectedStatus: fiber.StatusBadRequest, expectedBody: "Minimum quantity 600 is greater than maximum quantity 100."},
+		{name: "InvalidOutcome", query: "?outcome=lost", expectedStatus: fiber.StatusBadRequest, expectedBody: "Outcome must be one of: exact, over, under."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, page := session.do("GET", "/history"+tt.query, nil)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			for _, id := range []string{exact, over, large} {
+				if slices.Contains(tt.expectedIDs, id) {
+					assert.Contains(t, page, "/history/"+id)
+				} else {
+					assert.NotContains(t, page, "/history/"+id)
+				}
+			}
+			assert.Contains(t, page, tt.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+	"pack_optimizer/pkg/idempotency"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestIdempotencyKeys checks replay, key reuse and concurrent retries against both record stores.
+func TestIdempotencyKeys(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open("file:idempotency?mode=memory&cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.Exec(`CREATE TABLE IF NOT EXISTS idempotency_records (
+		record_key VARCHAR(512) PRIMARY KEY,
+		request_hash CHAR(64) NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		content_type VARCHAR(255) NOT NULL DEFAULT '',
+		body BLOB,
+		expires_at DATETIME NOT NULL
+	)`).Error)
+
+	stores := map[string]func() idempotency.Store{
+		"memory":   func() idempotency.Store { return idempotency.NewMemoryStore() },
+		"database": func() idempotency.Store { return sqlrepo.NewIdempotencyStore(gormDB) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			gormDB.Exec("DELETE FROM idempotency_records")
+
+			var calls atomic.Int32
+			entered, release := make(chan struct{}), make(chan struct{})
+
+			app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+			app.Use(recover.New())
+			// Stand-in for AuthMiddleware: the X-Client header names the principal.
+			app.Use(func(c *fiber.Ctx) error {
+				if subject := c.Get("X-Client"); subject != "" {
+					c.Locals("principal", domain.Principal{Subject: subject})
+				}
+				return c.Next()
+			})
+			app.Use(middlewares.IdempotencyMiddleware(newStore(), time.Hour))
+			app.Post("/calculate", func(c *fiber.Ctx) error {
+				var req struct {
+					Quantity int `json:"quantity"`
+				}
+				_ = c.BodyParser(&req)
+				if req.Quantity <= 0 {
+					return domain.ErrQuantityOutOfRange
+				}
+				return c.JSON(fiber.Map{"call": calls.Add(1), "quantity": req.Quantity})
+			})
+			app.Post("/unstable", func(c *fiber.Ctx) error {
+				if calls.Add(1) == 1 {
+					return errors.New("database connection failed")
+				}
+				return c.SendString("ok")
+			})
+			app.Post("/panicky", func(c *fiber.Ctx) error {
+				if calls.Add(1) == 1 {
+					panic("solver bug")
+				}
+				return c.SendString("ok")
+			})
+			app.Post("/import", func(c *fiber.Ctx) error {
+				return c.JSON(fiber.Map{"call": calls.Add(1), "dry_run": c.QueryBool("dry_run")})
+			})
+			app.Post("/slow", func(c *fiber.Ctx) error {
+				close(entered)
+				<-release
+				return c.SendString("done")
+			})
+
+			send := func(path, client, key, body string) (*http.Response, string) {
+				req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Client", client)
+				req.Header.Set(middlewares.IdempotencyKeyHeader, key)
+				resp, err := app.Test(req, -1)
+				assert.NoError(t, err)
+				respBody, _ := io.ReadAll(resp.Body)
+				return resp, string(respBody)
+			}
+
+			// The first response is replayed on retry without calling the handler again.
+			resp, first := send("/calculate", "erp", "order-1", `{"quantity": 501}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.JSONEq(t, `{"call": 1, "quantity": 501}`, first)
+			assert.Empty(t, resp.Header.Get(middlewares.IdempotentReplayedHeader))
+
+			resp, replayed := send("/calculate", "erp", "order-1", `{"quantity": 501}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Equal(t, first, replayed)
+			assert.Equal(t, "true", resp.Header.Get(middlewares.IdempotentReplayedHeader))
+			assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+			assert.Equal(t, int32(1), calls.Load())
+
+			// The same key with another body is rejected.
+			resp, body := send("/calculate", "erp", "order-1", `{"quantity": 502}`)
+			assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+			var problem map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(body), &problem))
+			assert.Equal(t, "IDEMPOTENCY_KEY_REUSED", problem["code"])
+
+			// Keys are scoped per client.
+			resp, body = send("/calculate", "billing", "order-1", `{"quantity": 502}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.JSONEq(t, `{"call": 2, "quantity": 502}`, body)
+
+			// Client errors are stored and replayed as well.
+			resp, first = send("/calculate", "erp", "order-2", `{"quantity": 0}`)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			resp, replayed = send("/calculate", "erp", "order-2", `{"quantity": 0}`)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, first, replayed)
+			assert.Equal(t, customerrrors.ProblemContentType, resp.Header.Get("Content-Type"))
+
+			// Server errors are not stored, so the retry runs the handler again.
+			calls.Store(0)
+			resp, _ = send("/unstable", "erp", "order-3", `{}`)
+			assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+			resp, body = send("/unstable", "erp", "order-3", `{}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Equal(t, "ok", body)
+
+			// A panic releases the key, so the retry runs the handler again.
+			calls.Store(0)
+			resp, _ = send("/panicky", "erp", "order-5", `{}`)
+			assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+			resp, body = send("/panicky", "erp", "order-5", `{}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Equal(t, "ok", body)
+
+			// A dry run and the real request differ in their query string only; the key can not be reused across them.
+			calls.Store(0)
+			resp, body = send("/import?dry_run=true", "erp", "import-1", `{"sizes": [250]}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.JSONEq(t, `{"call": 1, "dry_run": true}`, body)
+			resp, body = send("/import", "erp", "import-1", `{"sizes": [250]}`)
+			assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+			assert.Contains(t, body, "IDEMPOTENCY_KEY_REUSED")
+			resp, body = send("/import", "erp", "import-2", `{"sizes": [250]}`)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.JSONEq(t, `{"call": 2, "dry_run": false}`, body)
+
+			// A retry that arrives while the first request is running is rejected.
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				resp, body := send("/slow", "erp", "order-4", `{}`)
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+				assert.Equal(t, "done", body)
+			}()
+			<-entered
+			resp, body = send("/slow", "erp", "order-4", `{}`)
+			assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+			assert.Contains(t, body, "IDEMPOTENCY_KEY_IN_USE")
+			close(release)
+			<-done
+		})
+	}
+}
+
+// TestIdempotencyKeys_ScopedPerAPIKey checks that two API keys of the same name do not share records.
+func TestIdempotencyKeys_ScopedPerAPIKey(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.AutoMigrate(&domain.APIKey{}))
+	keys := apikeyusecase.NewAPIKeyUseCase(sqlrepo.NewAPIKeyRepo(gormDB))
+	first, err := keys.Issue(t.Context(), "erp", domain.RoleCalculator)
+	assert.NoError(t, err)
+	second, err := keys.Issue(t.Context(), "erp", domain.RoleCalculator)
+	assert.NoError(t, err)
+
+	var calls atomic.Int32
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+	app.Use(middlewares.AuthMiddleware(keys, nil))
+	app.Use(middlewares.IdempotencyMiddleware(idempotency.NewMemoryStore(), time.Hour))
+	app.Post("/calculate", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"call": calls.Add(1)})
+	})
+
+	send := func(apiKey string) (*http.Response, string) {
+		req := httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"quantity": 501}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middlewares.APIKeyHeader, apiKey)
+		req.Header.Set(middlewares.IdempotencyKeyHeader, "order-1")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := send(first.Key)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"call": 1}`, body)
+
+	resp, body = send(second.Key)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"call": 2}`, body)
+	assert.Empty(t, resp.Header.Get(middlewares.IdempotentReplayedHeader))
+}
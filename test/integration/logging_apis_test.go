@@ -0,0 +1,180 @@
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/handler/packhandler"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/packusecase"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestRequestScopedLogging checks that access and error logs share the request-scoped fields.
+func TestRequestScopedLogging(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+	app.Use(middlewares.RequestIDMiddleware)
+	app.Use(middlewares.LoggerMiddleware)
+	app.Get("/boom", func(_ *fiber.Ctx) error {
+		return errors.New("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/boom", nil)
+	req.Header.Set("X-Request-ID", "req-456")
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+
+	if assert.Len(t, entries, 2) {
+		accessEntry, errorEntry := entries[0], entries[1]
+		for _, entry := range entries {
+			assert.Equal(t, "req-456", entry["request_id"])
+			assert.Equal(t, "GET", entry["method"])
+			assert.Equal(t, "/boom", entry["path"])
+			assert.Equal(t, float64(fiber.StatusInternalServerError), entry["status_code"])
+		}
+		assert.Equal(t, "request failed", errorEntry["message"])
+		assert.Equal(t, "boom", errorEntry["error"])
+		assert.Equal(t, "request handled", accessEntry["message"])
+	}
+}
+
+// TestAccessLogStatus checks that the access log records the status the client receives, including
+// errors that the central error handler turns into a 4xx response.
+func TestAccessLogStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        fiber.Handler
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			handler:        func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) },
+			expectedStatus: fiber.StatusCreated,
+		},
+		{
+			name:           "DomainError",
+			handler:        func(_ *fiber.Ctx) error { return domain.ErrNoPacksAvailable },
+			expectedStatus: fiber.StatusNotFound,
+		},
+		{
+			name:           "APIError",
+			handler:        func(_ *fiber.Ctx) error { return customerrrors.InvalidField("quantity", "min", "must be at least 1") },
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:           "FiberError",
+			handler:        func(_ *fiber.Ctx) error { return fiber.ErrUnprocessableEntity },
+			expectedStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:           "UnexpectedError",
+			handler:        func(_ *fiber.Ctx) error { return errors.New("boom") },
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			previous := log.Logger
+			log.Logger = zerolog.New(&buf)
+			t.Cleanup(func() { log.Logger = previous })
+
+			app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+			app.Use(middlewares.RequestIDMiddleware)
+			app.Use(middlewares.LoggerMiddleware)
+			app.Get("/", tt.handler)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			var accessEntry map[string]interface{}
+			scanner := bufio.NewScanner(&buf)
+			for scanner.Scan() {
+				var entry map[string]interface{}
+				assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+				if entry["message"] == "request handled" {
+					accessEntry = entry
+				}
+			}
+			if assert.NotNil(t, accessEntry) {
+				assert.Equal(t, float64(tt.expectedStatus), accessEntry["status_code"])
+			}
+		})
+	}
+}
+
+// TestAccessLogStreamedSweep checks that logging a sweep does not buffer its streamed body.
+func TestAccessLogStreamedSweep(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	gormDB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.AutoMigrate(&domain.Pack{}))
+	packRepo := sqlrepo.NewPackRepo(gormDB)
+	require.NoError(t, packRepo.UpdatePacks(t.Context(), []int{250, 500}, nil))
+	packHandler := packhandler.NewPackHandler(packusecase.NewPackUseCase(packRepo))
+
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+	app.Use(middlewares.RequestIDMiddleware)
+	app.Use(middlewares.LoggerMiddleware)
+	app.Get("/api/v1/packs/sweep", packHandler.Sweep)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/packs/sweep?from=1&to=1000", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	// A buffered body would have been sent with a Content-Length.
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+	var rows int
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		rows++
+	}
+	assert.Equal(t, 1000, rows)
+
+	var accessEntry map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["message"] == "request handled" {
+			accessEntry = entry
+		}
+	}
+	if assert.NotNil(t, accessEntry) {
+		assert.Equal(t, float64(fiber.StatusOK), accessEntry["status_code"])
+		assert.NotContains(t, accessEntry, "bytes_sent")
+	}
+}
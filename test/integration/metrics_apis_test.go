@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"pack_optimizer/internal/handler/middlewares"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetricsApi checks that /metrics exposes the HTTP and solver metrics after a calculation.
+func TestMetricsApi(t *testing.T) {
+	app := fiber.New()
+	app.Use(middlewares.MetricsMiddleware)
+	app.Mount("/", newTestApp(t))
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
+	req := httptest.NewRequest("POST", "/api/v1/packs/calculate", bytes.NewReader([]byte(`{"quantity": 501}`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	metrics := string(body)
+	assert.Contains(t, metrics,
+		`pack_optimizer_http_requests_total{method="POST",route="/api/v1/packs/calculate",status="200"}`)
+	assert.Contains(t, metrics, `pack_optimizer_solve_duration_seconds_count{operation="calculate"}`)
+	assert.Contains(t, metrics, `pack_optimizer_solve_states_explored_count{operation="calculate"}`)
+	assert.Contains(t, metrics, `pack_optimizer_solve_queue_peak_size_count{operation="calculate"}`)
+	assert.Contains(t, metrics, `pack_optimizer_db_get_all_packs_duration_seconds_count`)
+}
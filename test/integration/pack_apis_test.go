@@ -3,23 +3,26 @@ package integration
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http/httptest"
+	"pack_optimizer/internal/handler/customerrrors"
 	"pack_optimizer/internal/handler/packhandler"
 	"pack_optimizer/internal/repository/sqlrepo"
 	"pack_optimizer/internal/usecase/packusecase"
+	"strings"
 	"testing"
 
 	"pack_optimizer/internal/domain"
 
+	"github.com/glebarez/sqlite"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// TestCalculatePackApi is a comprehensive integration test for the /api/v1/packs/calculate endpoint.
-// It uses the real handler and use case, and repository with an in-memory database.
-func TestCalculatePackApi(t *testing.T) {
+// newTestApp wires the real handler, use case and repository to an in-memory database
+// seeded with the default pack sizes.
+func newTestApp(t *testing.T) *fiber.App {
 	// 1. Setup the in-memory SQLite database
 	gormDB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	assert.NoError(t, err)
@@ -34,6 +37,7 @@ func TestCalculatePackApi(t *testing.T) {
 	}
 	err = gormDB.AutoMigrate(&domain.Pack{})
 	assert.NoError(t, err)
+	gormDB.Where("1 = 1").Delete(&domain.Pack{})
 	gormDB.Create(&packs)
 
 	// 3. Initialize the real application components with the mock database
@@ -41,11 +45,39 @@ func TestCalculatePackApi(t *testing.T) {
 	packUseCase := packusecase.NewPackUseCase(packRepo)
 	packHandler := packhandler.NewPackHandler(packUseCase)
 
-	// 4. Setup the Fiber app with the real handler
-	app := fiber.New()
+	// 4. Setup the Fiber app with the real handler and the central error handler
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
 	app.Post("/api/v1/packs/calculate", packHandler.CalculatePacks)
+	app.Post("/api/v1/packs/pareto", packHandler.ParetoFront)
+	app.Get("/api/v1/packs/sweep", packHandler.Sweep)
+	app.Post("/api/v1/packs/reverse", packHandler.ReverseLookup)
+
+	return app
+}
+
+// problem builds the RFC 7807 body the central error handler returns, plus any extension members.
+func problem(status int, code, title, detail, instance string, extensions map[string]interface{}) map[string]interface{} {
+	body := map[string]interface{}{
+		"type":       "urn:pack-optimizer:problem:" + strings.ReplaceAll(strings.ToLower(code), "_", "-"),
+		"title":      title,
+		"status":     float64(status),
+		"detail":     detail,
+		"instance":   instance,
+		"code":       code,
+		"request_id": "unknown",
+	}
+	for key, value := range extensions {
+		body[key] = value
+	}
+	return body
+}
+
+// TestCalculatePackApi is a comprehensive integration test for the /api/v1/packs/calculate endpoint.
+// It uses the real handler and use case, and repository with an in-memory database.
+func TestCalculatePackApi(t *testing.T) {
+	app := newTestApp(t)
 
-	// 5. Define a table of test cases
+	// Define a table of test cases
 	tests := []struct {
 		name           string
 		requestBody    map[string]interface{}
@@ -59,6 +91,7 @@ func TestCalculatePackApi(t *testing.T) {
 			expectedBody: map[string]interface{}{
 				"total_items":     float64(750),
 				"remaining_items": float64(249),
+				"difference":      float64(249),
 				"total_packs":     float64(2),
 				"packs": []interface{}{
 					map[string]interface{}{"size": float64(250), "count": float64(1)},
@@ -73,6 +106,7 @@ func TestCalculatePackApi(t *testing.T) {
 			expectedBody: map[string]interface{}{
 				"total_items":     float64(1250),
 				"remaining_items": float64(16),
+				"difference":      float64(16),
 				"total_packs":     float64(2),
 				"packs": []interface{}{
 					map[string]interface{}{"size": float64(250), "count": float64(1)},
@@ -84,19 +118,24 @@ func TestCalculatePackApi(t *testing.T) {
 			name:           "BadRequest_ZeroQuantity",
 			requestBody:    map[string]interface{}{"quantity": 0},
 			expectedStatus: fiber.StatusBadRequest,
-			expectedBody:   map[string]interface{}{"error": "Key: 'CalculatePacksReq.Quantity' Error:Field validation for 'Quantity' failed on the 'required' tag"},
+			expectedBody: problem(fiber.StatusBadRequest, "VALIDATION_FAILED", "Request validation failed", "request validation failed", "/api/v1/packs/calculate", map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"field": "quantity", "rule": "required", "message": "is required"}},
+			}),
 		},
 		{
 			name:           "BadRequest_InvalidInput_Negative",
 			requestBody:    map[string]interface{}{"quantity": -10},
 			expectedStatus: fiber.StatusBadRequest,
-			expectedBody:   map[string]interface{}{"error": "Key: 'CalculatePacksReq.Quantity' Error:Field validation for 'Quantity' failed on the 'min' tag"},
+			expectedBody: problem(fiber.StatusBadRequest, "VALIDATION_FAILED", "Request validation failed", "request validation failed", "/api/v1/packs/calculate", map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"field": "quantity", "rule": "min", "message": "must be at least 1"}},
+			}),
 		},
 		{
 			name:           "BadRequest_InvalidInput_String",
 			requestBody:    map[string]interface{}{"quantity": "not a number"},
 			expectedStatus: fiber.StatusBadRequest,
-			expectedBody:   map[string]interface{}{"error": "invalid request"},
+			expectedBody: problem(fiber.StatusBadRequest, "INVALID_REQUEST", "Request could not be parsed",
+				"request could not be parsed", "/api/v1/packs/calculate", nil),
 		},
 		{
 			name:           "BigQuantity_ValidInput_1000000",
@@ -105,19 +144,85 @@ func TestCalculatePackApi(t *testing.T) {
 			expectedBody: map[string]interface{}{
 				"total_items":     float64(1000000),
 				"remaining_items": float64(0),
+				"difference":      float64(0),
 				"total_packs":     float64(200),
 				"packs": []interface{}{
 					map[string]interface{}{"size": float64(5000), "count": float64(200)},
 				},
 			},
 		},
+		{
+			name:           "Success_ExactOnly_750",
+			requestBody:    map[string]interface{}{"quantity": 750, "exact_only": true},
+			expectedStatus: fiber.StatusOK,
+			expectedBody: map[string]interface{}{
+				"total_items":     float64(750),
+				"remaining_items": float64(0),
+				"difference":      float64(0),
+				"total_packs":     float64(2),
+				"packs": []interface{}{
+					map[string]interface{}{"size": float64(250), "count": float64(1)},
+					map[string]interface{}{"size": float64(500), "count": float64(1)},
+				},
+			},
+		},
+		{
+			name:           "Unprocessable_ExactOnly_1234",
+			requestBody:    map[string]interface{}{"quantity": 1234, "exact_only": true},
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedBody: problem(fiber.StatusUnprocessableEntity, "NO_EXACT_FIT", "No exact pack combination",
+				"no pack combination adds up to exactly 1234 items", "/api/v1/packs/calculate", map[string]interface{}{
+					"nearest_below": float64(1000),
+					"nearest_above": float64(1250),
+				}),
+		},
+		{
+			name:           "Success_Tolerance_Under_1010",
+			requestBody:    map[string]interface{}{"quantity": 1010, "tolerance": map[string]interface{}{"under": 1, "unit": "percent"}},
+			expectedStatus: fiber.StatusOK,
+			expectedBody: map[string]interface{}{
+				"total_items":     float64(1000),
+				"remaining_items": float64(0),
+				"difference":      float64(-10),
+				"total_packs":     float64(1),
+				"packs": []interface{}{
+					map[string]interface{}{"size": float64(1000), "count": float64(1)},
+				},
+			},
+		},
+		{
+			name:           "Unprocessable_Tolerance_1100",
+			requestBody:    map[string]interface{}{"quantity": 1100, "tolerance": map[string]interface{}{"under": 50, "over": 100}},
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedBody: problem(fiber.StatusUnprocessableEntity, "OUTSIDE_TOLERANCE", "No pack combination within tolerance",
+				"no pack combination between 1050 and 1200 items", "/api/v1/packs/calculate", map[string]interface{}{
+					"nearest_below": float64(1000),
+					"nearest_above": float64(1250),
+				}),
+		},
+		{
+			name:           "BadRequest_Tolerance_InvalidUnit",
+			requestBody:    map[string]interface{}{"quantity": 1100, "tolerance": map[string]interface{}{"under": 5, "unit": "items"}},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: problem(fiber.StatusBadRequest, "VALIDATION_FAILED", "Request validation failed", "request validation failed", "/api/v1/packs/calculate", map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"field": "tolerance.unit", "rule": "oneof", "message": "must be one of: absolute, percent"}},
+			}),
+		},
+		{
+			name:           "BadRequest_Tolerance_TooLarge",
+			requestBody:    map[string]interface{}{"quantity": 1100, "tolerance": map[string]interface{}{"over": 1e20}},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: problem(fiber.StatusBadRequest, "VALIDATION_FAILED", "Request validation failed", "request validation failed", "/api/v1/packs/calculate", map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"field": "tolerance.over", "rule": "max", "message": "must be at most 99999999"}},
+			}),
+		},
 		{
 			name:           "Overflow_ValidInput_999999999",
 			requestBody:    map[string]interface{}{"quantity": 999999999},
 			expectedStatus: fiber.StatusBadRequest,
-			expectedBody: map[string]interface{}{
-				"error": "Key: 'CalculatePacksReq.Quantity' Error:Field validation for 'Quantity' failed on the 'max' tag",
-			},
+			expectedBody: problem(fiber.StatusBadRequest, "VALIDATION_FAILED", "Request validation failed", "request validation failed", "/api/v1/packs/calculate", map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"field": "quantity", "rule": "max", "message": "must be at most 99999999"}},
+			}),
 		},
 	}
 
@@ -146,3 +251,145 @@ func TestCalculatePackApi(t *testing.T) {
 		})
 	}
 }
+
+// TestParetoFrontApi checks the /api/v1/packs/pareto endpoint end to end.
+func TestParetoFrontApi(t *testing.T) {
+	app := newTestApp(t)
+
+	body, err := json.Marshal(map[string]interface{}{"quantity": 1234})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/packs/pareto", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var output packusecase.ParetoOutput
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&output))
+	assert.Equal(t, 1234, output.Quantity)
+	if assert.Len(t, output.Points, 2) {
+		assert.Equal(t, 1250, output.Points[0].TotalItems)
+		assert.Equal(t, 2, output.Points[0].TotalPacks)
+		assert.Equal(t, 2000, output.Points[1].TotalItems)
+		assert.Equal(t, 1, output.Points[1].TotalPacks)
+	}
+}
+
+// TestSweepApi checks that /api/v1/packs/sweep streams one row per quantity in both formats.
+func TestSweepApi(t *testing.T) {
+	app := newTestApp(t)
+
+	t.Run("NDJSON", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/packs/sweep?from=250&to=1250&step=250", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		var rows []packusecase.SweepRow
+		dec := json.NewDecoder(resp.Body)
+		for dec.More() {
+			var row packusecase.SweepRow
+			assert.NoError(t, dec.Decode(&row))
+			rows = append(rows, row)
+		}
+		if assert.Len(t, rows, 5) {
+			assert.Equal(t, 250, rows[0].Quantity)
+			assert.Equal(t, 1, rows[0].TotalPacks)
+			assert.Equal(t, 750, rows[2].TotalItems)
+			assert.Equal(t, 2, rows[2].TotalPacks)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/packs/sweep?from=1&to=3&format=csv", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "quantity,total_items,remaining_items,total_packs,pack_250,pack_500,pack_1000,pack_2000,pack_5000\n"+
+			"1,250,249,1,1,0,0,0,0\n"+
+			"2,250,248,1,1,0,0,0,0\n"+
+			"3,250,247,1,1,0,0,0,0\n", string(body))
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/packs/sweep?from=10&to=5", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+// TestReverseLookupApi checks the /api/v1/packs/reverse endpoint end to end.
+func TestReverseLookupApi(t *testing.T) {
+	app := newTestApp(t)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "Success_Optimal",
+			requestBody:    `{"packs": {"5000": 1, "250": 1}}`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody: map[string]interface{}{
+				"total_items": float64(5250),
+				"total_packs": float64(2),
+				"optimal":     true,
+				"from":        float64(5001),
+				"to":          float64(5250),
+			},
+		},
+		{
+			name:           "Success_NotOptimal",
+			requestBody:    `{"packs": {"5000": 1, "250": 2}}`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody: map[string]interface{}{
+				"total_items": float64(5500),
+				"total_packs": float64(3),
+				"optimal":     false,
+				"optimal_packs": []interface{}{
+					map[string]interface{}{"size": float64(500), "count": float64(1)},
+					map[string]interface{}{"size": float64(5000), "count": float64(1)},
+				},
+			},
+		},
+		{
+			name:           "Unprocessable_UnknownSize",
+			requestBody:    `{"packs": {"300": 1}}`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedBody: problem(fiber.StatusUnprocessableEntity, "UNKNOWN_PACK_SIZE", "Unknown pack size",
+				"pack size 300: unknown pack size", "/api/v1/packs/reverse", nil),
+		},
+		{
+			name:           "BadRequest_Empty",
+			requestBody:    `{"packs": {}}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody: problem(fiber.StatusBadRequest, "VALIDATION_FAILED", "Request validation failed", "request validation failed", "/api/v1/packs/reverse", map[string]interface{}{
+				"errors": []interface{}{map[string]interface{}{"field": "packs", "rule": "min", "message": "must contain at least 1 entries"}},
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/packs/reverse", bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			var responseBody map[string]interface{}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&responseBody))
+			assert.Equal(t, tt.expectedBody, responseBody)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/packsethandler"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/internal/usecase/packsetusecase"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newPackSetTestApp serves the pack set endpoints over a SQLite database holding sizes.
+func newPackSetTestApp(t *testing.T, sizes ...int) (*fiber.App, domain.PackSetRepository) {
+	gormDB, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.AutoMigrate(&domain.Pack{}))
+	repo := sqlrepo.NewPackRepo(gormDB)
+	require.NoError(t, repo.UpdatePacks(t.Context(), sizes, nil))
+
+	handler := packsethandler.NewPackSetHandler(packsetusecase.NewPackSetUseCase(repo), "staging")
+	app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+	app.Get("/api/v1/packsets/export", handler.Export)
+	app.Post("/api/v1/packsets/import", handler.Import)
+	return app, repo
+}
+
+func TestPackSetExportApi(t *testing.T) {
+	app, _ := newPackSetTestApp(t, 500, 250)
+
+	t.Run("JSON", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/packsets/export", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, fiber.MIMEApplicationJSONCharsetUTF8, resp.Header.Get("Content-Type"))
+
+		var doc packsetusecase.Document
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+		assert.Equal(t, packsetusecase.DocumentVersion, doc.Version)
+		assert.Equal(t, "staging", doc.Source)
+		assert.Equal(t, []packsetusecase.DocumentPack{{Size: 250}, {Size: 500}}, doc.Packs)
+	})
+
+	t.Run("YAML_ByAcceptHeader", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/packsets/export", nil)
+		req.Header.Set("Accept", "application/yaml")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		body, _ := io.ReadAll(resp.Body)
+		doc, err := packsetusecase.DecodeDocument(body, packsetusecase.FormatYAML)
+		require.NoError(t, err)
+		assert.Len(t, doc.Packs, 2)
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/packsets/export?format=xml", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestPackSetImportApi(t *testing.T) {
+	document := "version: 1\npacks:\n  - size: 250\n  - size: 1000\n"
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		expectedSizes  []int
+	}{
+		{
+			name:           "DryRun",
+			query:          "?dry_run=true",
+			contentType:    "application/yaml",
+			body:           document,
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"dry_run":true,"mode":"replace","diff":{"added":[1000],"removed":[500],"unchanged":[250]}}`,
+			expectedSizes:  []int{250, 500},
+		},
+		{
+			name:           "Replace",
+			contentType:    "application/yaml",
+			body:           document,
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"dry_run":false,"mode":"replace","diff":{"added":[1000],"removed":[500],"unchanged":[250]}}`,
+			expectedSizes:  []int{250, 1000},
+		},
+		{
+			name:           "Merge_JSON",
+			query:          "?mode=merge",
+			contentType:    "application/json",
+			body:           `{"version":1,"packs":[{"size":1000}]}`,
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"dry_run":false,"mode":"merge","diff":{"added":[1000],"removed":[],"unchanged":[250,500]}}`,
+			expectedSizes:  []int{250, 500, 1000},
+		},
+		{
+			name:           "UnsupportedVersion",
+			contentType:    "application/json",
+			body:           `{"version":2,"packs":[{"size":1000}]}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `"code":"INVALID_PACK_SET"`,
+			expectedSizes:  []int{250, 500},
+		},
+		{
+			name:           "UnknownField",
+			contentType:    "application/json",
+			body:           `{"version":1,"sizes":[1000]}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `"code":"INVALID_PACK_SET"`,
+			expectedSizes:  []int{250, 500},
+		},
+		{
+			name:           "UnsupportedMediaType",
+			contentType:    "text/plain",
+			body:           document,
+			expectedStatus: fiber.StatusUnsupportedMediaType,
+			expectedSizes:  []int{250, 500},
+		},
+		{
+			name:           "InvalidMode",
+			query:          "?mode=append",
+			contentType:    "application/yaml",
+			body:           document,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `"field":"mode"`,
+			expectedSizes:  []int{250, 500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, repo := newPackSetTestApp(t, 250, 500)
+
+			req := httptest.NewRequest("POST", "/api/v1/packsets/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			packs, err := repo.GetAllPacks(t.Context())
+			require.NoError(t, err)
+			sizes := make([]int, len(packs))
+			for i, pack := range packs {
+				sizes[i] = pack.Size
+			}
+			assert.Equal(t, tt.expectedSizes, sizes)
+		})
+	}
+}
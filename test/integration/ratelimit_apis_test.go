@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/handler/customerrrors"
+	"pack_optimizer/internal/handler/middlewares"
+	"pack_optimizer/internal/handler/packhandler"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"pack_optimizer/pkg/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestRateLimit checks the request and quota limits against both bucket stores.
+func TestRateLimit(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open("file:ratelimit?mode=memory&cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.Exec(`CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+		bucket_key VARCHAR(255) PRIMARY KEY,
+		tokens DOUBLE PRECISION NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`).Error)
+
+	stores := map[string]func() ratelimit.Store{
+		"memory":   func() ratelimit.Store { return ratelimit.NewMemoryStore() },
+		"database": func() ratelimit.Store { return sqlrepo.NewRateLimitStore(gormDB) },
+	}
+	limits := middlewares.RateLimits{
+		Requests: ratelimit.Limit{Rate: 1, Burst: 3},
+		Quota:    ratelimit.Limit{Rate: 100, Burst: 1000},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			gormDB.Exec("DELETE FROM rate_limit_buckets")
+
+			app := fiber.New(fiber.Config{ErrorHandler: customerrrors.ErrorHandler})
+			// Stand-in for AuthMiddleware: the X-Client header names the principal.
+			app.Use(func(c *fiber.Ctx) error {
+				if subject := c.Get("X-Client"); subject != "" {
+					c.Locals("principal", domain.Principal{Subject: subject})
+				}
+				return c.Next()
+			})
+			app.Post("/calculate", middlewares.RateLimitMiddleware(newStore(), limits, packhandler.QuantityCost),
+				func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+			send := func(client string, quantity int) *httptest.ResponseRecorder {
+				body, _ := json.Marshal(map[string]int{"quantity": quantity})
+				req := httptest.NewRequest("POST", "/calculate", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Client", client)
+				resp, err := app.Test(req, -1)
+				assert.NoError(t, err)
+
+				rec := httptest.NewRecorder()
+				rec.Code = resp.StatusCode
+				for key, values := range resp.Header {
+					rec.Header()[key] = values
+				}
+				_, _ = rec.Body.ReadFrom(resp.Body)
+				return rec
+			}
+
+			// The quota bucket is drained by a large order, then refuses a second one.
+			resp := send("alice", 800)
+			assert.Equal(t, fiber.StatusOK, resp.Code)
+			assert.Equal(t, `"requests";q=3;w=3, "quota";q=1000;w=10`, resp.Header().Get("RateLimit-Policy"))
+			assert.Equal(t, `"requests";r=2;t=1, "quota";r=200;t=8`, resp.Header().Get("RateLimit"))
+
+			resp = send("alice", 800)
+			assert.Equal(t, fiber.StatusTooManyRequests, resp.Code)
+			assert.Equal(t, "6", resp.Header().Get("Retry-After"))
+			var problem map[string]interface{}
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+			assert.Equal(t, "RATE_LIMITED", problem["code"])
+			assert.Equal(t, "quota limit exceeded, retry in 6 seconds", problem["detail"])
+			assert.Equal(t, float64(6), problem["retry_after"])
+			assert.Equal(t, `"requests";r=2;t=1, "quota";r=200;t=8`, resp.Header().Get("RateLimit"),
+				"a request rejected by the quota is not charged to the request bucket")
+
+			// An order the quota can never hold is refused outright and charged to neither bucket.
+			resp = send("alice", 1500)
+			assert.Equal(t, fiber.StatusRequestEntityTooLarge, resp.Code)
+			assert.Empty(t, resp.Header().Get("Retry-After"))
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+			assert.Equal(t, "COST_TOO_HIGH", problem["code"])
+			assert.Equal(t, float64(1000), problem["max_cost"])
+
+			// Small orders still fit into the quota until the request bucket runs out.
+			assert.Equal(t, fiber.StatusOK, send("alice", 10).Code)
+			assert.Equal(t, fiber.StatusOK, send("alice", 10).Code)
+			resp = send("alice", 10)
+			assert.Equal(t, fiber.StatusTooManyRequests, resp.Code)
+			assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+			assert.Equal(t, "requests limit exceeded, retry in 1 seconds", problem["detail"])
+
+			// Every client has its own buckets.
+			assert.Equal(t, fiber.StatusOK, send("bob", 800).Code)
+		})
+	}
+}
+
+// TestRateLimitStore_DeleteIdle checks that only buckets untouched for a day are deleted.
+func TestRateLimitStore_DeleteIdle(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open("file:ratelimit_idle?mode=memory&cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.Exec(`CREATE TABLE rate_limit_buckets (
+		bucket_key VARCHAR(255) PRIMARY KEY,
+		tokens DOUBLE PRECISION NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`).Error)
+	store := sqlrepo.NewRateLimitStore(gormDB)
+	limit := ratelimit.Limit{Rate: 1, Burst: 3}
+
+	_, err = store.Take(t.Context(),
+		ratelimit.Charge{Key: "requests:idle", Cost: 1, Limit: limit},
+		ratelimit.Charge{Key: "requests:active", Cost: 1, Limit: limit})
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.Exec("UPDATE rate_limit_buckets SET updated_at = ? WHERE bucket_key = ?",
+		time.Now().Add(-25*time.Hour), "requests:idle").Error)
+
+	assert.NoError(t, store.DeleteIdle(t.Context()))
+	var keys []string
+	assert.NoError(t, gormDB.Raw("SELECT bucket_key FROM rate_limit_buckets").Scan(&keys).Error)
+	assert.Equal(t, []string{"requests:active"}, keys)
+}
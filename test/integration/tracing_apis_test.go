@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"pack_optimizer/internal/handler/middlewares"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestCalculatePackApiTracing checks that a calculate call produces handler, use case, solver and
+// repository spans that continue the trace from the incoming traceparent header.
+func TestCalculatePackApiTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	app := fiber.New()
+	app.Use(middlewares.RequestIDMiddleware)
+	app.Use(middlewares.TracingMiddleware)
+	app.Mount("/", newTestApp(t))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("POST", "/api/v1/packs/calculate", bytes.NewReader([]byte(`{"quantity": 501}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String(), "span %s must join the incoming trace", span.Name())
+	}
+
+	for _, name := range []string{
+		"PackHandler.CalculatePacks",
+		"PackUseCase.CalculatePacks",
+		"packusecase.findBestPackCombination",
+		"PackRepo.GetAllPacks",
+	} {
+		assert.Contains(t, spans, name)
+	}
+
+	if handlerSpan, ok := spans["PackHandler.CalculatePacks"]; ok {
+		assert.Contains(t, handlerSpan.Attributes(), attribute.String("request_id", "req-123"))
+		assert.Equal(t, "00f067aa0ba902b7", handlerSpan.Parent().SpanID().String())
+	}
+}
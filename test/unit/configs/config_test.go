@@ -0,0 +1,162 @@
+package configstest
+
+import (
+	"bytes"
+	"os"
+	"pack_optimizer/configs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setRequiredEnv provides every setting that has no default.
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(configs.ConfigFileEnv, "")
+	for key, value := range map[string]string{
+		"ENV":         "test",
+		"APP_PORT":    "8080",
+		"DB_HOST":     "localhost",
+		"DB_PORT":     "5432",
+		"DB_USER":     "packs",
+		"DB_PASSWORD": "s3cret",
+		"DB_NAME":     "packs",
+		"DB_SSLMODE":  "disable",
+	} {
+		t.Setenv(key, value)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := configs.LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "8080", cfg.App.Port)
+	assert.Equal(t, 10*time.Second, cfg.App.ReadTimeout)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, 99999999, cfg.Solver.MaxQuantity)
+	assert.Equal(t, configs.DefaultMaxPackSize, cfg.Solver.MaxPackSize)
+	assert.Equal(t, configs.DefaultMaxStates, cfg.Solver.MaxStates)
+	assert.Equal(t, "host=localhost port=5432 user=packs password=s3cret dbname=packs sslmode=disable statement_timeout=30000", cfg.DB.GormDSN)
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("APP_PORT", "")
+	os.Unsetenv("APP_PORT")
+	file := writeFile(t, "config.yaml", `
+app:
+  port: "7000"
+  read_timeout: 5s
+log:
+  level: debug
+solver:
+  max_quantity: 1000
+`)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("SOLVER_MAX_QUANTITY", "2000")
+
+	cfg, err := configs.LoadConfig([]string{"--config", file, "--solver.max_quantity=3000"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "7000", cfg.App.Port, "file overrides the missing env")
+	assert.Equal(t, 5*time.Second, cfg.App.ReadTimeout, "file overrides the default")
+	assert.Equal(t, 60*time.Second, cfg.App.WriteTimeout, "default is kept")
+	assert.Equal(t, "warn", cfg.Log.Level, "env overrides the file")
+	assert.Equal(t, 3000, cfg.Solver.MaxQuantity, "flag overrides env and file")
+}
+
+func TestLoadConfig_TenantsFromEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("AUTH_JWT_TENANTS", "acme,globex")
+
+	cfg, err := configs.LoadConfig(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "globex"}, cfg.Auth.JWT.Tenants)
+}
+
+func TestLoadConfig_TOMLFileFromEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv(configs.ConfigFileEnv, writeFile(t, "config.toml", `
+[admission]
+max_concurrent = 4
+max_wait = "500ms"
+`))
+
+	cfg, err := configs.LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, cfg.Admission.MaxConcurrent)
+	assert.Equal(t, 500*time.Millisecond, cfg.Admission.MaxWait)
+}
+
+func TestLoadConfig_ReturnsErrors(t *testing.T) {
+	t.Run("missing required setting", func(t *testing.T) {
+		setRequiredEnv(t)
+		os.Unsetenv("DB_PASSWORD")
+
+		_, err := configs.LoadConfig(nil)
+		assert.ErrorContains(t, err, "Password")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		_, err := configs.LoadConfig([]string{"--log.format=xml"})
+		assert.ErrorContains(t, err, "Format")
+	})
+
+	t.Run("missing config file", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		_, err := configs.LoadConfig([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")})
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+
+	t.Run("tenants without a tenant claim", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("AUTH_JWT_TENANTS", "acme")
+
+		_, err := configs.LoadConfig([]string{"--auth.jwt_tenant_claim="})
+		assert.ErrorContains(t, err, "TenantClaim")
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		_, err := configs.LoadConfig([]string{"--app.prot=1"})
+		assert.Error(t, err)
+	})
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := configs.LoadConfig(nil)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, configs.Print(&out, cfg))
+
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Contains(t, out.String(), "password: <redacted>")
+	assert.Contains(t, out.String(), "read_timeout: 10s")
+
+	// The printed document is itself a valid config file.
+	file := writeFile(t, "printed.yaml", out.String())
+	reloaded, err := configs.LoadConfig([]string{"--config", file})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.App, reloaded.App)
+	assert.Equal(t, cfg.Solver, reloaded.Solver)
+}
@@ -0,0 +1,66 @@
+package dbtest
+
+import (
+	"context"
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachable points at a port where nothing listens, so every connection attempt is refused.
+func unreachable() configs.DB {
+	cfg := poolConfig()
+	cfg.Driver = db.DriverPostgres
+	cfg.GormDSN = "host=127.0.0.1 port=1 user=u password=p dbname=d sslmode=disable connect_timeout=1"
+	return cfg
+}
+
+func poolConfig() configs.DB {
+	return configs.DB{
+		Driver:            db.DriverPostgres,
+		MaxOpenConns:      3,
+		MaxIdleConns:      2,
+		ConnMaxLifetime:   time.Minute,
+		ConnectAttempts:   3,
+		ConnectBackoff:    10 * time.Millisecond,
+		ConnectMaxBackoff: 20 * time.Millisecond,
+	}
+}
+
+func TestConnect_SQLiteKeepsOneConnection(t *testing.T) {
+	cfg := poolConfig()
+	cfg.Driver = db.DriverSQLite
+	cfg.GormDSN = filepath.Join(t.TempDir(), "packs.db")
+
+	gormDB, err := db.Connect(context.Background(), cfg)
+	require.NoError(t, err)
+
+	stats := db.PoolStats(gormDB)
+	assert.Equal(t, 1, stats["max_open"])
+	assert.Equal(t, 1, stats["open"])
+}
+
+func TestConnect_GivesUpWhenUnreachable(t *testing.T) {
+	start := time.Now()
+	_, err := db.Connect(context.Background(), unreachable())
+	assert.ErrorContains(t, err, "database unreachable after 3 attempts")
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond, "waits 10ms and then 20ms between attempts")
+}
+
+func TestConnect_StopsWhenCancelled(t *testing.T) {
+	cfg := unreachable()
+	cfg.ConnectAttempts = 100
+	cfg.ConnectBackoff = time.Hour
+	cfg.ConnectMaxBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := db.Connect(ctx, cfg)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,212 @@
+package dbtest
+
+import (
+	"context"
+	"os"
+	"pack_optimizer/configs"
+	"pack_optimizer/db"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/repository/sqlrepo"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migrationsDir holds one directory of migrations per driver.
+const migrationsDir = "../../../db/migrations"
+
+func TestRunMigrations_SQLiteInMemory(t *testing.T) {
+	cfg, err := loadSQLiteConfig(t, ":memory:")
+	require.NoError(t, err)
+
+	gormDB, err := db.Connect(context.Background(), cfg.DB)
+	require.NoError(t, err)
+	require.NoError(t, db.RunMigrations(cfg.DB, gormDB))
+
+	current, dirty, err := db.CurrentVersion(context.Background(), gormDB)
+	require.NoError(t, err)
+	latest, err := db.LatestVersion(db.DriverSQLite)
+	require.NoError(t, err)
+	assert.False(t, dirty)
+	assert.Equal(t, latest, current)
+
+	// Migrations create the schema only, and the repositories work against it.
+	packRepo := sqlrepo.NewPackRepo(gormDB)
+	_, err = packRepo.GetAllPacks(context.Background())
+	assert.ErrorIs(t, err, domain.ErrNoPacksAvailable)
+
+	require.NoError(t, packRepo.UpdatePacks(context.Background(), []int{500, 250}, nil))
+	require.NoError(t, packRepo.UpdatePacks(context.Background(), []int{250, 1000}, []int{500}), "existing sizes are skipped")
+	packs, err := packRepo.GetAllPacks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Pack{{Size: 250}, {Size: 1000}}, packs)
+
+	_, err = sqlrepo.NewAPIKeyRepo(gormDB).GetAPIKeyByHash(context.Background(), "missing")
+	assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+	calculationRepo := sqlrepo.NewCalculationRepo(gormDB)
+	calculation := domain.Calculation{
+		ID:         "5f0c1c51-52a1-4d5c-9a55-0d8ab5cf0f36",
+		Quantity:   501,
+		Tolerance:  domain.CalculationTolerance{Under: 1, Over: 2.5, Unit: "percent"},
+		TotalItems: 750,
+		TotalPacks: 2,
+		Difference: 249,
+		Packs:      []domain.CalculationPack{{Size: 500, Count: 1}, {Size: 250, Count: 1}},
+		CreatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(t, calculationRepo.CreateCalculation(context.Background(), &calculation))
+	found, err := calculationRepo.GetCalculation(context.Background(), calculation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, calculation.Packs, found.Packs)
+	assert.Equal(t, calculation.Tolerance, found.Tolerance)
+	assert.True(t, calculation.CreatedAt.Equal(found.CreatedAt))
+	listed, err := calculationRepo.ListCalculations(context.Background(), domain.CalculationFilter{Outcome: domain.OutcomeOver})
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+	deleted, err := calculationRepo.DeleteCalculationsOlderThan(context.Background(), calculation.CreatedAt)
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "calculations recorded at the cutoff are kept")
+	deleted, err = calculationRepo.DeleteCalculationsOlderThan(context.Background(), calculation.CreatedAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestMigrator_UpDownGotoForce(t *testing.T) {
+	cfg, err := loadSQLiteConfig(t, filepath.Join(t.TempDir(), "packs.db"))
+	require.NoError(t, err)
+	gormDB, err := db.Connect(context.Background(), cfg.DB)
+	require.NoError(t, err)
+
+	migrator, err := db.NewMigrator(cfg.DB, gormDB)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), status.Current)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Up(), "nothing to apply is not an error")
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, status.Latest, status.Current)
+	assert.True(t, gormDB.Migrator().HasColumn("calculations", "tolerance_unit"))
+
+	require.NoError(t, migrator.Down(2))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, status.Latest-2, status.Current)
+	assert.True(t, gormDB.Migrator().HasTable("calculations"))
+	assert.False(t, gormDB.Migrator().HasColumn("calculations", "tolerance_unit"))
+
+	require.NoError(t, migrator.Goto(1))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Current)
+	assert.True(t, gormDB.Migrator().HasTable("packs"))
+	assert.False(t, gormDB.Migrator().HasTable("api_keys"))
+
+	assert.Error(t, migrator.Down(0))
+
+	require.NoError(t, migrator.Force(3))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, migrationStatusOf(3), status)
+}
+
+// TestMigrations_RemoveSamplePacks checks that upgrading removes the sample sizes 001 inserted, but
+// only from a pack set that holds nothing else.
+func TestMigrations_RemoveSamplePacks(t *testing.T) {
+	tests := []struct {
+		name          string
+		add           []int
+		remove        []int
+		expectedSizes []int
+	}{
+		{name: "Untouched", expectedSizes: []int{}},
+		{name: "SomeRemoved", remove: []int{2000, 5000}, expectedSizes: []int{}},
+		{name: "Changed", add: []int{42}, remove: []int{5000}, expectedSizes: []int{42, 250, 500, 1000, 2000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadSQLiteConfig(t, filepath.Join(t.TempDir(), "packs.db"))
+			require.NoError(t, err)
+			gormDB, err := db.Connect(context.Background(), cfg.DB)
+			require.NoError(t, err)
+			migrator, err := db.NewMigrator(cfg.DB, gormDB)
+			require.NoError(t, err)
+			defer migrator.Close()
+
+			require.NoError(t, migrator.Goto(6))
+			packRepo := sqlrepo.NewPackRepo(gormDB)
+			require.NoError(t, packRepo.UpdatePacks(context.Background(), tt.add, tt.remove))
+			require.NoError(t, migrator.Up())
+
+			var sizes []int
+			require.NoError(t, gormDB.Model(&domain.Pack{}).Order("size").Pluck("size", &sizes).Error)
+			assert.ElementsMatch(t, tt.expectedSizes, sizes)
+
+			if len(tt.expectedSizes) == 0 {
+				require.NoError(t, migrator.Down(1))
+				require.NoError(t, gormDB.Model(&domain.Pack{}).Order("size").Pluck("size", &sizes).Error)
+				assert.Equal(t, []int{250, 500, 1000, 2000, 5000}, sizes)
+			}
+		})
+	}
+}
+
+// migrationStatusOf returns the status of a clean database at version.
+func migrationStatusOf(version uint) db.MigrationStatus {
+	latest, _ := db.LatestVersion(db.DriverSQLite)
+	return db.MigrationStatus{Current: version, Latest: latest}
+}
+
+// TestMigrations_EveryUpHasDown checks that each up migration of each driver can be rolled back.
+func TestMigrations_EveryUpHasDown(t *testing.T) {
+	drivers, err := os.ReadDir(migrationsDir)
+	require.NoError(t, err)
+
+	for _, driver := range drivers {
+		t.Run(driver.Name(), func(t *testing.T) {
+			files, err := os.ReadDir(filepath.Join(migrationsDir, driver.Name()))
+			require.NoError(t, err)
+
+			names := map[string]bool{}
+			for _, file := range files {
+				names[file.Name()] = true
+			}
+			for name := range names {
+				if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+					assert.True(t, names[base+".down.sql"], "%s has no %s.down.sql", name, base)
+				} else if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+					assert.True(t, names[base+".up.sql"], "%s has no %s.up.sql", name, base)
+				} else {
+					t.Errorf("%s is neither an up nor a down migration", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLatestVersion_SameForEveryDriver(t *testing.T) {
+	postgres, err := db.LatestVersion(db.DriverPostgres)
+	require.NoError(t, err)
+	sqlite, err := db.LatestVersion(db.DriverSQLite)
+	require.NoError(t, err)
+	assert.Equal(t, postgres, sqlite)
+}
+
+// loadSQLiteConfig loads a configuration that selects SQLite without any Postgres settings.
+func loadSQLiteConfig(t *testing.T, path string) (configs.Config, error) {
+	t.Helper()
+	t.Setenv(configs.ConfigFileEnv, "")
+	t.Setenv("ENV", "test")
+	t.Setenv("APP_PORT", "8080")
+	return configs.LoadConfig([]string{"--db.driver=sqlite", "--db.path=" + path})
+}
@@ -0,0 +1,54 @@
+package dbtest
+
+import (
+	"os"
+	"pack_optimizer/db"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedsDir holds the seed file of each environment.
+const seedsDir = "../../../db/seeds"
+
+func TestLoadSeed_EveryEnvironment(t *testing.T) {
+	files, err := os.ReadDir(seedsDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		env := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		t.Run(env, func(t *testing.T) {
+			seed, err := db.LoadSeed(env)
+			require.NoError(t, err)
+			assert.NotEmpty(t, seed.Packs)
+		})
+	}
+}
+
+func TestLoadSeed_UnknownEnvironment(t *testing.T) {
+	_, err := db.LoadSeed("production")
+	assert.ErrorIs(t, err, db.ErrNoSeed)
+}
+
+func TestReadSeedFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	seed, err := db.ReadSeedFile(write("ok.yml", "packs: [23, 31, 53]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []int{23, 31, 53}, seed.Packs)
+
+	_, err = db.ReadSeedFile(write("typo.json", `{"pack": [23]}`))
+	assert.ErrorContains(t, err, "unknown field")
+
+	_, err = db.ReadSeedFile(write("seed.txt", "packs: [23]"))
+	assert.ErrorContains(t, err, "must be .yaml, .yml or .json")
+}
@@ -0,0 +1,112 @@
+package jwkstest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"pack_optimizer/pkg/jwks"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issuer serves a key set holding one RSA key, counting requests. While failing is set it answers 500,
+// and while gate is set every request waits for it to be closed.
+type issuer struct {
+	doc      []byte
+	requests atomic.Int32
+	failing  atomic.Bool
+	gate     chan struct{}
+}
+
+func newIssuer(t *testing.T) (*issuer, *httptest.Server) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	doc, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kid": "test-key",
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+
+	iss := &issuer{doc: doc}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		iss.requests.Add(1)
+		if iss.gate != nil {
+			<-iss.gate
+		}
+		if iss.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(iss.doc)
+	}))
+	t.Cleanup(server.Close)
+	return iss, server
+}
+
+// keyConcurrently calls Key from n goroutines at once and returns their errors.
+func keyConcurrently(ks *jwks.KeySet, kid string, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = ks.Key(context.Background(), kid)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestKeySet_CoalescesRefetches(t *testing.T) {
+	iss, server := newIssuer(t)
+	ks, err := jwks.Load(context.Background(), server.URL, time.Second)
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+
+	// The refetch is held until every caller had time to ask; it then stays fresh for a second.
+	iss.gate = make(chan struct{})
+	time.AfterFunc(50*time.Millisecond, func() { close(iss.gate) })
+	for _, err := range keyConcurrently(ks, "test-key", 20) {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), iss.requests.Load(), "one request to load, one shared by all callers")
+}
+
+func TestKeySet_FailingIssuer(t *testing.T) {
+	iss, server := newIssuer(t)
+	ks, err := jwks.Load(context.Background(), server.URL, time.Nanosecond)
+	require.NoError(t, err)
+	iss.failing.Store(true)
+
+	// Cached keys are still served, and after one failed refetch the issuer is left alone.
+	for _, err := range keyConcurrently(ks, "test-key", 20) {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), iss.requests.Load())
+
+	_, err = ks.Key(context.Background(), "rotated-key")
+	assert.ErrorIs(t, err, jwks.ErrKeyNotFound)
+	assert.ErrorContains(t, err, "unexpected status 500")
+	assert.Equal(t, int32(2), iss.requests.Load(), "unknown keys do not refetch while backing off")
+}
+
+func TestLoad_FailingIssuer(t *testing.T) {
+	iss, server := newIssuer(t)
+	iss.failing.Store(true)
+
+	_, err := jwks.Load(context.Background(), server.URL, time.Hour)
+	assert.ErrorContains(t, err, "unexpected status 500")
+}
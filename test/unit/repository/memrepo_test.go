@@ -0,0 +1,37 @@
+package repositorytest
+
+import (
+	"context"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/repository/memrepo"
+	"pack_optimizer/internal/usecase/packusecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemPackRepo(t *testing.T) {
+	repo := memrepo.NewPackRepo([]int{500, 250, 500})
+
+	packs, err := repo.GetAllPacks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Pack{{Size: 250}, {Size: 500}}, packs)
+
+	require.NoError(t, repo.UpdatePacks(context.Background(), []int{1000, 250}, []int{500}))
+	packs, err = repo.GetAllPacks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Pack{{Size: 250}, {Size: 1000}}, packs)
+
+	require.NoError(t, repo.UpdatePacks(context.Background(), nil, []int{250, 1000}))
+	_, err = repo.GetAllPacks(context.Background())
+	assert.ErrorIs(t, err, domain.ErrNoPacksAvailable)
+}
+
+func TestMemPackRepo_BacksPackUseCase(t *testing.T) {
+	uc := packusecase.NewPackUseCase(memrepo.NewPackRepo([]int{23, 31, 53}))
+
+	output, err := uc.CalculatePacks(context.Background(), 500000)
+	require.NoError(t, err)
+	assert.Equal(t, 500000, output.TotalItems)
+}
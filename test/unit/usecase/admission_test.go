@@ -0,0 +1,94 @@
+package usecasetest
+
+import (
+	"context"
+	"errors"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/packusecase"
+	"pack_optimizer/pkg/admission"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAdmittedUseCase(queueDepth int, maxWait time.Duration) (*packusecase.AdmittedPackUseCase, *admission.Controller) {
+	controller := admission.NewController(1, queueDepth, maxWait)
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{{Size: 250}, {Size: 500}}})
+	return packusecase.NewAdmittedPackUseCase(uc, controller), controller
+}
+
+func TestAdmission_RejectsWhenQueueIsFull(t *testing.T) {
+	uc, controller := newAdmittedUseCase(0, time.Second)
+
+	release, err := controller.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	_, err = uc.CalculatePacks(context.Background(), 251)
+	assert.ErrorIs(t, err, domain.ErrOverloaded)
+	var overloadErr *packusecase.OverloadError
+	assert.True(t, errors.As(err, &overloadErr))
+	assert.ErrorIs(t, overloadErr.Reason, admission.ErrQueueFull)
+	assert.Equal(t, time.Second, overloadErr.RetryAfter)
+
+	release()
+	output, err := uc.CalculatePacks(context.Background(), 251)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, output.TotalItems)
+}
+
+func TestAdmission_WaitsForAFreeSlot(t *testing.T) {
+	uc, controller := newAdmittedUseCase(1, 20*time.Millisecond)
+
+	release, err := controller.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	// Nobody releases the slot, so the queued call times out.
+	_, err = uc.ParetoFront(context.Background(), 251, 0)
+	var overloadErr *packusecase.OverloadError
+	assert.True(t, errors.As(err, &overloadErr))
+	assert.ErrorIs(t, overloadErr.Reason, admission.ErrWaitTimeout)
+
+	// A slot released while the call is queued is handed over.
+	uc, controller = newAdmittedUseCase(1, time.Second)
+	release, err = controller.Acquire(context.Background())
+	assert.NoError(t, err)
+	time.AfterFunc(10*time.Millisecond, release)
+	_, err = uc.ReverseLookup(context.Background(), map[int]int{500: 1})
+	assert.NoError(t, err)
+
+	// A canceled caller leaves the queue with the context error.
+	_, err = controller.Acquire(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = uc.CalculatePacks(ctx, 251)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAdmission_SweepHoldsSlotUntilRowsAreComputed(t *testing.T) {
+	uc, _ := newAdmittedUseCase(0, time.Second)
+
+	sweeper, err := uc.NewSweeper(context.Background(), 1, 10, 1)
+	assert.NoError(t, err)
+
+	_, err = uc.CalculatePacks(context.Background(), 251)
+	assert.ErrorIs(t, err, domain.ErrOverloaded)
+
+	// The rows are emitted after the slot is released, so other solves run while a client reads them.
+	var rows int
+	assert.NoError(t, sweeper.Run(context.Background(), func(packusecase.SweepRow) error {
+		rows++
+		_, err := uc.CalculatePacks(context.Background(), 251)
+		return err
+	}))
+	assert.Equal(t, 10, rows)
+	_, err = uc.CalculatePacks(context.Background(), 251)
+	assert.NoError(t, err)
+
+	// A sweep that fails validation gives its slot back immediately.
+	_, err = uc.NewSweeper(context.Background(), 10, 1, 1)
+	assert.ErrorIs(t, err, domain.ErrInvalidRange)
+	_, err = uc.CalculatePacks(context.Background(), 251)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,93 @@
+package usecasetest
+
+import (
+	"context"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/apikeyusecase"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryAPIKeyRepo keeps API keys in a slice.
+type memoryAPIKeyRepo struct {
+	keys []domain.APIKey
+}
+
+func (m *memoryAPIKeyRepo) CreateAPIKey(_ context.Context, key *domain.APIKey) error {
+	key.ID = uint(len(m.keys) + 1)
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *memoryAPIKeyRepo) GetAPIKeyByHash(_ context.Context, keyHash string) (domain.APIKey, error) {
+	for _, key := range m.keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return domain.APIKey{}, domain.ErrAPIKeyNotFound
+}
+
+func (m *memoryAPIKeyRepo) RevokeAPIKey(_ context.Context, id uint, revokedAt time.Time) error {
+	for i := range m.keys {
+		if m.keys[i].ID == id {
+			m.keys[i].RevokedAt = &revokedAt
+			return nil
+		}
+	}
+	return domain.ErrAPIKeyNotFound
+}
+
+func TestAPIKey_IssueAndAuthenticate(t *testing.T) {
+	repo := &memoryAPIKeyRepo{}
+	uc := apikeyusecase.NewAPIKeyUseCase(repo)
+
+	issued, err := uc.Issue(context.Background(), "erp", domain.RolePackAdmin)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Key, "po_"))
+	assert.True(t, strings.HasPrefix(issued.Key, issued.Prefix))
+
+	// Only the hash is stored.
+	assert.Len(t, repo.keys, 1)
+	assert.Equal(t, apikeyusecase.HashKey(issued.Key), repo.keys[0].KeyHash)
+	assert.NotContains(t, repo.keys[0].KeyHash, issued.Key)
+
+	principal, err := uc.Authenticate(context.Background(), issued.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Principal{Subject: "1", Name: "erp", Roles: []domain.Role{domain.RolePackAdmin}, APIKey: true}, principal)
+	assert.True(t, principal.HasRole(domain.RoleCalculator, domain.RolePackAdmin))
+	assert.False(t, principal.HasRole(domain.RoleAuditor))
+
+	// Names need not be unique, so a second key of the same name is a different principal.
+	other, err := uc.Issue(context.Background(), "erp", domain.RolePackAdmin)
+	assert.NoError(t, err)
+	otherPrincipal, err := uc.Authenticate(context.Background(), other.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "erp", otherPrincipal.Name)
+	assert.NotEqual(t, principal.Subject, otherPrincipal.Subject)
+}
+
+func TestAPIKey_Errors(t *testing.T) {
+	uc := apikeyusecase.NewAPIKeyUseCase(&memoryAPIKeyRepo{})
+
+	_, err := uc.Issue(context.Background(), "erp", domain.Role("root"))
+	assert.ErrorIs(t, err, domain.ErrInvalidRole)
+
+	_, err = uc.Issue(context.Background(), " ", domain.RoleCalculator)
+	assert.EqualError(t, err, "api key name must not be empty")
+
+	_, err = uc.Authenticate(context.Background(), "po_unknown")
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+
+	err = uc.Revoke(context.Background(), 42)
+	assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+	issued, err := uc.Issue(context.Background(), "erp", domain.RoleCalculator)
+	assert.NoError(t, err)
+	assert.NoError(t, uc.Revoke(context.Background(), issued.ID))
+	_, err = uc.Authenticate(context.Background(), issued.Key)
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+}
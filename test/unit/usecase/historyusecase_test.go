@@ -0,0 +1,192 @@
+package usecasetest
+
+import (
+	"context"
+	"errors"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/historyusecase"
+	"pack_optimizer/internal/usecase/packusecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// calculationMockRepo keeps calculations in memory and records the last filter it was asked for.
+type calculationMockRepo struct {
+	calculations []domain.Calculation
+	filter       domain.CalculationFilter
+	err          error
+}
+
+// DeleteCalculationsOlderThan keeps only the calculations recorded at or after cutoff.
+func (m *calculationMockRepo) DeleteCalculationsOlderThan(_ context.Context, cutoff time.Time) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	kept := m.calculations[:0]
+	for _, calculation := range m.calculations {
+		if !calculation.CreatedAt.Before(cutoff) {
+			kept = append(kept, calculation)
+		}
+	}
+	deleted := int64(len(m.calculations) - len(kept))
+	m.calculations = kept
+	return deleted, nil
+}
+
+func (m *calculationMockRepo) CreateCalculation(_ context.Context, calculation *domain.Calculation) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.calculations = append(m.calculations, *calculation)
+	return nil
+}
+
+func (m *calculationMockRepo) GetCalculation(_ context.Context, id string) (domain.Calculation, error) {
+	for _, calculation := range m.calculations {
+		if calculation.ID == id {
+			return calculation, nil
+		}
+	}
+	return domain.Calculation{}, domain.ErrCalculationNotFound
+}
+
+func (m *calculationMockRepo) ListCalculations(_ context.Context, filter domain.CalculationFilter) ([]domain.Calculation, error) {
+	m.filter = filter
+	return nil, m.err
+}
+
+func newRecordingUseCase(repo *calculationMockRepo) *historyusecase.RecordingPackUseCase {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{{Size: 250}, {Size: 500}, {Size: 1000}}})
+	return historyusecase.NewRecordingPackUseCase(uc, historyusecase.NewHistoryUseCase(repo))
+}
+
+func TestRecordingPackUseCase_RecordsCalculations(t *testing.T) {
+	repo := &calculationMockRepo{}
+	uc := newRecordingUseCase(repo)
+
+	output, err := uc.CalculatePacksWithOptions(context.Background(), 501, packusecase.CalculateOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, output.ID)
+	require.Len(t, repo.calculations, 1)
+
+	recorded := repo.calculations[0]
+	assert.Equal(t, output.ID, recorded.ID)
+	assert.Equal(t, 501, recorded.Quantity)
+	assert.Equal(t, 750, recorded.TotalItems)
+	assert.Equal(t, 249, recorded.Difference)
+	assert.Equal(t, domain.OutcomeOver, recorded.Outcome())
+	assert.ElementsMatch(t, []domain.CalculationPack{{Size: 500, Count: 1}, {Size: 250, Count: 1}}, recorded.Packs)
+	assert.Equal(t, time.UTC, recorded.CreatedAt.Location())
+	assert.Zero(t, recorded.Tolerance)
+
+	history := historyusecase.NewHistoryUseCase(repo)
+	found, err := history.Get(context.Background(), output.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, found)
+}
+
+func TestRecordingPackUseCase_RecordsOptions(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              packusecase.CalculateOptions
+		expectedExactOnly bool
+		expectedTolerance domain.CalculationTolerance
+	}{
+		{
+			name:              "ExactOnly",
+			opts:              packusecase.CalculateOptions{ExactOnly: true},
+			expectedExactOnly: true,
+		},
+		{
+			name:              "PercentTolerance",
+			opts:              packusecase.CalculateOptions{Tolerance: &packusecase.Tolerance{Under: 1, Over: 2.5, Unit: packusecase.TolerancePercent}},
+			expectedTolerance: domain.CalculationTolerance{Under: 1, Over: 2.5, Unit: "percent"},
+		},
+		{
+			name:              "UnitDefaultsToAbsolute",
+			opts:              packusecase.CalculateOptions{Tolerance: &packusecase.Tolerance{Over: 10}},
+			expectedTolerance: domain.CalculationTolerance{Over: 10, Unit: "absolute"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &calculationMockRepo{}
+			_, err := newRecordingUseCase(repo).CalculatePacksWithOptions(context.Background(), 500, tt.opts)
+			require.NoError(t, err)
+			require.Len(t, repo.calculations, 1)
+			assert.Equal(t, tt.expectedExactOnly, repo.calculations[0].ExactOnly)
+			assert.Equal(t, tt.expectedTolerance, repo.calculations[0].Tolerance)
+		})
+	}
+}
+
+func TestRecordingPackUseCase_SkipsFailures(t *testing.T) {
+	t.Run("failed calculations are not recorded", func(t *testing.T) {
+		repo := &calculationMockRepo{}
+		_, err := newRecordingUseCase(repo).CalculatePacksWithOptions(context.Background(), 251, packusecase.CalculateOptions{ExactOnly: true})
+		assert.ErrorIs(t, err, domain.ErrNoExactFit)
+		assert.Empty(t, repo.calculations)
+	})
+
+	t.Run("results that cannot be recorded are still returned", func(t *testing.T) {
+		repo := &calculationMockRepo{err: errors.New("database is read-only")}
+		output, err := newRecordingUseCase(repo).CalculatePacksWithOptions(context.Background(), 250, packusecase.CalculateOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, output.ID)
+		assert.Equal(t, 250, output.TotalItems)
+	})
+}
+
+func TestHistoryGet_UnknownIDs(t *testing.T) {
+	history := historyusecase.NewHistoryUseCase(&calculationMockRepo{})
+
+	for _, id := range []string{"", "not-a-uuid", "5f0c1c51-52a1-4d5c-9a55-0d8ab5cf0f36"} {
+		_, err := history.Get(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrCalculationNotFound, id)
+	}
+}
+
+func TestHistoryList_Filters(t *testing.T) {
+	repo := &calculationMockRepo{}
+	history := historyusecase.NewHistoryUseCase(repo)
+
+	calculations, err := history.List(context.Background(), domain.CalculationFilter{MinQuantity: 100})
+	require.NoError(t, err)
+	assert.Empty(t, calculations)
+	assert.NotNil(t, calculations)
+	assert.Equal(t, historyusecase.DefaultLimit, repo.filter.Limit)
+
+	invalid := []domain.CalculationFilter{
+		{MinQuantity: 500, MaxQuantity: 100},
+		{From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Limit: historyusecase.MaxLimit + 1},
+		{Offset: -1},
+	}
+	for _, filter := range invalid {
+		_, err := history.List(context.Background(), filter)
+		assert.ErrorIs(t, err, domain.ErrInvalidRange, "%+v", filter)
+	}
+}
+
+func TestHistoryPrune(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &calculationMockRepo{calculations: []domain.Calculation{
+		{ID: "old", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "recent", CreatedAt: now.Add(-time.Hour)},
+	}}
+	history := historyusecase.NewHistoryUseCase(repo)
+
+	deleted, err := history.Prune(context.Background(), 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	require.Len(t, repo.calculations, 1)
+	assert.Equal(t, "recent", repo.calculations[0].ID)
+
+	repo.err = errors.New("database is read-only")
+	_, err = history.Prune(context.Background(), 24*time.Hour)
+	assert.Error(t, err)
+}
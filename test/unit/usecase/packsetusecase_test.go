@@ -0,0 +1,192 @@
+package usecasetest
+
+import (
+	"bytes"
+	"context"
+	"pack_optimizer/internal/domain"
+	"pack_optimizer/internal/usecase/packsetusecase"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packSetMockRepo keeps pack sizes in memory and counts the updates it receives.
+type packSetMockRepo struct {
+	sizes   []int
+	updates int
+}
+
+func (m *packSetMockRepo) GetAllPacks(_ context.Context) ([]domain.Pack, error) {
+	if len(m.sizes) == 0 {
+		return nil, domain.ErrNoPacksAvailable
+	}
+	packs := make([]domain.Pack, len(m.sizes))
+	for i, size := range m.sizes {
+		packs[i] = domain.Pack{Size: size}
+	}
+	return packs, nil
+}
+
+func (m *packSetMockRepo) UpdatePacks(_ context.Context, add, remove []int) error {
+	m.updates++
+	m.sizes = slices.DeleteFunc(m.sizes, func(size int) bool { return slices.Contains(remove, size) })
+	for _, size := range add {
+		if !slices.Contains(m.sizes, size) {
+			m.sizes = append(m.sizes, size)
+		}
+	}
+	return nil
+}
+
+func TestPackSetApply_IsIdempotent(t *testing.T) {
+	repo := &packSetMockRepo{}
+	uc := packsetusecase.NewPackSetUseCase(repo)
+
+	diff, err := uc.Apply(context.Background(), []int{500, 250, 500}, packsetusecase.ApplyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{250, 500}, diff.Added)
+	assert.True(t, diff.Changed())
+
+	diff, err = uc.Apply(context.Background(), []int{250, 500}, packsetusecase.ApplyOptions{})
+	require.NoError(t, err)
+	assert.False(t, diff.Changed())
+	assert.Equal(t, []int{250, 500}, diff.Unchanged)
+	assert.Equal(t, 1, repo.updates, "an unchanged set is not written")
+}
+
+func TestPackSetApply_PruneAndDryRun(t *testing.T) {
+	repo := &packSetMockRepo{sizes: []int{250, 500, 1000}}
+	uc := packsetusecase.NewPackSetUseCase(repo)
+
+	diff, err := uc.Apply(context.Background(), []int{500, 2000}, packsetusecase.ApplyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, packsetusecase.Diff{Added: []int{2000}, Removed: []int{}, Unchanged: []int{250, 500, 1000}}, diff)
+
+	diff, err = uc.Apply(context.Background(), []int{500}, packsetusecase.ApplyOptions{Prune: true, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, packsetusecase.Diff{Added: []int{}, Removed: []int{250, 1000, 2000}, Unchanged: []int{500}}, diff)
+	assert.ElementsMatch(t, []int{250, 500, 1000, 2000}, repo.sizes, "a dry run writes nothing")
+
+	_, err = uc.Apply(context.Background(), []int{500}, packsetusecase.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []int{500}, repo.sizes)
+}
+
+func TestPackSetApply_InvalidSets(t *testing.T) {
+	uc := packsetusecase.NewPackSetUseCase(&packSetMockRepo{sizes: []int{250}})
+
+	_, err := uc.Apply(context.Background(), []int{250, 0}, packsetusecase.ApplyOptions{})
+	assert.ErrorIs(t, err, domain.ErrInvalidPackSet)
+
+	_, err = uc.Apply(context.Background(), nil, packsetusecase.ApplyOptions{Prune: true})
+	assert.ErrorIs(t, err, domain.ErrInvalidPackSet)
+}
+
+func TestPackSetImport_RejectsSizesAboveTheMaximum(t *testing.T) {
+	repo := &packSetMockRepo{sizes: []int{250}}
+	uc := packsetusecase.NewPackSetUseCase(repo, packsetusecase.WithMaxPackSize(10000))
+	doc := packsetusecase.Document{Version: packsetusecase.DocumentVersion, Packs: []packsetusecase.DocumentPack{{Size: 500}, {Size: 1000000000}}}
+
+	_, err := uc.Import(context.Background(), doc, packsetusecase.ImportOptions{})
+	assert.ErrorIs(t, err, domain.ErrInvalidPackSet)
+	assert.EqualError(t, err, "invalid pack set: pack size 1000000000 must not exceed 10000")
+	assert.Equal(t, 0, repo.updates, "nothing of an invalid set is written")
+
+	doc.Packs = doc.Packs[:1]
+	_, err = uc.Import(context.Background(), doc, packsetusecase.ImportOptions{})
+	assert.NoError(t, err)
+}
+
+func TestPackSetExportImport_RoundTrip(t *testing.T) {
+	staging := packsetusecase.NewPackSetUseCase(&packSetMockRepo{sizes: []int{500, 250}})
+	doc, err := staging.Export(context.Background(), "staging")
+	require.NoError(t, err)
+
+	for _, format := range []string{packsetusecase.FormatJSON, packsetusecase.FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, packsetusecase.EncodeDocument(&buf, doc, format))
+			decoded, err := packsetusecase.DecodeDocument(buf.Bytes(), format)
+			require.NoError(t, err)
+			assert.Equal(t, doc.Packs, decoded.Packs)
+			assert.True(t, doc.ExportedAt.Equal(decoded.ExportedAt))
+
+			production := &packSetMockRepo{sizes: []int{250, 1000}}
+			diff, err := packsetusecase.NewPackSetUseCase(production).Import(context.Background(), decoded, packsetusecase.ImportOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, []int{500}, diff.Added)
+			assert.Equal(t, []int{1000}, diff.Removed)
+			assert.ElementsMatch(t, []int{250, 500}, production.sizes)
+		})
+	}
+}
+
+func TestPackSetExport_EmptySet(t *testing.T) {
+	doc, err := packsetusecase.NewPackSetUseCase(&packSetMockRepo{}).Export(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, packsetusecase.DocumentVersion, doc.Version)
+	assert.Empty(t, doc.Packs)
+}
+
+func TestPackSetImport_RejectsOtherVersions(t *testing.T) {
+	uc := packsetusecase.NewPackSetUseCase(&packSetMockRepo{sizes: []int{250}})
+
+	_, err := uc.Import(context.Background(), packsetusecase.Document{Packs: []packsetusecase.DocumentPack{{Size: 500}}}, packsetusecase.ImportOptions{})
+	assert.ErrorIs(t, err, domain.ErrInvalidPackSet)
+}
+
+func TestPackSetAddSize(t *testing.T) {
+	repo := &packSetMockRepo{sizes: []int{250}}
+	uc := packsetusecase.NewPackSetUseCase(repo, packsetusecase.WithMaxPackSize(10000))
+
+	require.NoError(t, uc.AddSize(context.Background(), 500))
+	assert.ElementsMatch(t, []int{250, 500}, repo.sizes)
+
+	assert.ErrorIs(t, uc.AddSize(context.Background(), 500), domain.ErrInvalidPackSet, "duplicate")
+	assert.ErrorIs(t, uc.AddSize(context.Background(), 0), domain.ErrInvalidPackSet, "not positive")
+	assert.ErrorIs(t, uc.AddSize(context.Background(), 10001), domain.ErrInvalidPackSet, "above the maximum")
+	assert.Equal(t, 1, repo.updates)
+}
+
+func TestPackSetReplaceSize_PlansBeforeApplying(t *testing.T) {
+	repo := &packSetMockRepo{sizes: []int{250, 500}}
+	uc := packsetusecase.NewPackSetUseCase(repo)
+
+	diff, err := uc.PlanReplace(context.Background(), 500, 600)
+	require.NoError(t, err)
+	assert.Equal(t, packsetusecase.Diff{Added: []int{600}, Removed: []int{500}, Unchanged: []int{250}}, diff)
+	assert.Zero(t, repo.updates, "planning writes nothing")
+
+	applied, err := uc.ReplaceSize(context.Background(), 500, 600)
+	require.NoError(t, err)
+	assert.Equal(t, diff, applied)
+	assert.ElementsMatch(t, []int{250, 600}, repo.sizes)
+
+	_, err = uc.PlanReplace(context.Background(), 500, 700)
+	assert.ErrorIs(t, err, domain.ErrUnknownPackSize)
+	_, err = uc.PlanReplace(context.Background(), 600, 250)
+	assert.ErrorIs(t, err, domain.ErrInvalidPackSet)
+
+	diff, err = uc.ReplaceSize(context.Background(), 600, 600)
+	require.NoError(t, err)
+	assert.False(t, diff.Changed())
+	assert.Equal(t, 1, repo.updates)
+}
+
+func TestPackSetRemoveSize_KeepsTheLastSize(t *testing.T) {
+	repo := &packSetMockRepo{sizes: []int{250, 500}}
+	uc := packsetusecase.NewPackSetUseCase(repo)
+
+	diff, err := uc.RemoveSize(context.Background(), 500)
+	require.NoError(t, err)
+	assert.Equal(t, []int{500}, diff.Removed)
+	assert.Equal(t, []int{250}, repo.sizes)
+
+	_, err = uc.PlanRemove(context.Background(), 500)
+	assert.ErrorIs(t, err, domain.ErrUnknownPackSize)
+	_, err = uc.RemoveSize(context.Background(), 250)
+	assert.ErrorIs(t, err, domain.ErrInvalidPackSet)
+	assert.Equal(t, []int{250}, repo.sizes)
+}
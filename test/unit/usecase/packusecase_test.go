@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"pack_optimizer/configs"
 	"pack_optimizer/internal/domain"
 	"pack_optimizer/internal/usecase/packusecase"
 	"testing"
@@ -189,3 +191,320 @@ func TestCalculatePacks_RepoError(t *testing.T) {
 	assert.Equal(t, "use case failed to get packs: database connection failed", err.Error())
 	assert.Equal(t, packusecase.CalculatePacksOutput{}, result)
 }
+
+func TestCalculatePacks_ExactOnly(t *testing.T) {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{
+		{Size: 250},
+		{Size: 500},
+		{Size: 1000},
+		{Size: 2000},
+		{Size: 5000},
+	}})
+	opts := packusecase.CalculateOptions{ExactOnly: true}
+
+	t.Run("Exact order is accepted", func(t *testing.T) {
+		result, err := uc.CalculatePacksWithOptions(context.Background(), 750, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, 750, result.TotalItems)
+		assert.Equal(t, 0, result.RemainingItems)
+		assert.ElementsMatch(t, []packusecase.Pack{{Size: 250, Count: 1}, {Size: 500, Count: 1}}, result.Packs)
+	})
+
+	t.Run("Overshipping order is rejected with suggestions", func(t *testing.T) {
+		result, err := uc.CalculatePacksWithOptions(context.Background(), 1234, opts)
+		assert.ErrorIs(t, err, domain.ErrNoExactFit)
+
+		var fitErr *packusecase.NoFitError
+		assert.ErrorAs(t, err, &fitErr)
+		assert.Equal(t, 1000, fitErr.NearestBelow)
+		assert.Equal(t, 1250, fitErr.NearestAbove)
+		assert.Equal(t, packusecase.CalculatePacksOutput{}, result)
+	})
+
+	t.Run("Order below the smallest pack has no lower suggestion", func(t *testing.T) {
+		_, err := uc.CalculatePacksWithOptions(context.Background(), 100, opts)
+
+		var fitErr *packusecase.NoFitError
+		assert.ErrorAs(t, err, &fitErr)
+		assert.Equal(t, 0, fitErr.NearestBelow)
+		assert.Equal(t, 250, fitErr.NearestAbove)
+	})
+}
+
+func TestCalculatePacks_Tolerance(t *testing.T) {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{
+		{Size: 250},
+		{Size: 500},
+		{Size: 1000},
+		{Size: 2000},
+		{Size: 5000},
+	}})
+
+	tests := []struct {
+		name       string
+		orderQty   int
+		tolerance  packusecase.Tolerance
+		totalItems int
+		difference int
+		err        error
+	}{
+		{
+			name:       "Closer undershipment wins",
+			orderQty:   1010,
+			tolerance:  packusecase.Tolerance{Under: 10, Over: 500},
+			totalItems: 1000,
+			difference: -10,
+		},
+		{
+			name:       "Closer overshipment wins",
+			orderQty:   1240,
+			tolerance:  packusecase.Tolerance{Under: 500, Over: 500},
+			totalItems: 1250,
+			difference: 10,
+		},
+		{
+			name:       "Percentage window",
+			orderQty:   5100,
+			tolerance:  packusecase.Tolerance{Under: 2, Unit: packusecase.TolerancePercent},
+			totalItems: 5000,
+			difference: -100,
+		},
+		{
+			name:      "Nothing inside the window",
+			orderQty:  1100,
+			tolerance: packusecase.Tolerance{Under: 50, Over: 100},
+			err:       domain.ErrOutsideTolerance,
+		},
+		{
+			name:       "Huge overshipment window",
+			orderQty:   1240,
+			tolerance:  packusecase.Tolerance{Over: 1e20},
+			totalItems: 1250,
+			difference: 10,
+		},
+		{
+			name:       "Huge percentage window",
+			orderQty:   1240,
+			tolerance:  packusecase.Tolerance{Under: 1e20, Over: 1e20, Unit: packusecase.TolerancePercent},
+			totalItems: 1250,
+			difference: 10,
+		},
+		{
+			name:       "Infinite overshipment window",
+			orderQty:   1240,
+			tolerance:  packusecase.Tolerance{Over: math.Inf(1)},
+			totalItems: 1250,
+			difference: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := packusecase.CalculateOptions{Tolerance: &tt.tolerance}
+			result, err := uc.CalculatePacksWithOptions(context.Background(), tt.orderQty, opts)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.totalItems, result.TotalItems)
+			assert.Equal(t, tt.difference, result.Difference)
+			assert.Equal(t, max(tt.difference, 0), result.RemainingItems)
+		})
+	}
+}
+
+func TestParetoFront(t *testing.T) {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{
+		{Size: 23},
+		{Size: 31},
+		{Size: 53},
+	}})
+
+	result, err := uc.ParetoFront(context.Background(), 500, 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Points)
+
+	best, err := uc.CalculatePacks(context.Background(), 500)
+	assert.NoError(t, err)
+	assert.Equal(t, best.TotalItems, result.Points[0].TotalItems, "first point must be the regular optimum")
+	assert.Equal(t, best.TotalPacks, result.Points[0].TotalPacks, "first point must be the regular optimum")
+
+	for i := 1; i < len(result.Points); i++ {
+		prev, curr := result.Points[i-1], result.Points[i]
+		assert.Greater(t, curr.RemainingItems, prev.RemainingItems, "overage must grow along the front")
+		assert.Less(t, curr.TotalPacks, prev.TotalPacks, "pack count must shrink along the front")
+	}
+
+	limited, err := uc.ParetoFront(context.Background(), 500, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, limited.Points, min(2, len(result.Points))) {
+		assert.Equal(t, result.Points[0], limited.Points[0])
+		assert.Equal(t, result.Points[len(result.Points)-1], limited.Points[len(limited.Points)-1])
+	}
+}
+
+func TestSweep_MatchesCalculatePacks(t *testing.T) {
+	repo := &dynamicMockRepo{packs: []domain.Pack{
+		{Size: 23},
+		{Size: 31},
+		{Size: 53},
+	}}
+
+	tests := []struct {
+		name     string
+		maxTable int
+	}{
+		{name: "Unbounded table", maxTable: 0},
+		{name: "Table capped mid-range", maxTable: 300},
+		{name: "Table capped below the range", maxTable: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := packusecase.NewPackUseCase(repo, packusecase.WithLimits(packusecase.Limits{MaxTable: tt.maxTable}))
+			sweeper, err := uc.NewSweeper(context.Background(), 1, 600, 7)
+			assert.NoError(t, err)
+			assert.Equal(t, []int{23, 31, 53}, sweeper.PackSizes())
+
+			var quantities []int
+			err = sweeper.Run(context.Background(), func(row packusecase.SweepRow) error {
+				quantities = append(quantities, row.Quantity)
+
+				expected, calcErr := uc.CalculatePacks(context.Background(), row.Quantity)
+				assert.NoError(t, calcErr)
+				assert.Equal(t, expected.TotalItems, row.TotalItems, "quantity %d", row.Quantity)
+				assert.Equal(t, expected.TotalPacks, row.TotalPacks, "quantity %d", row.Quantity)
+				assert.Equal(t, expected.RemainingItems, row.RemainingItems, "quantity %d", row.Quantity)
+
+				packsSum := 0
+				for _, pack := range row.Packs {
+					packsSum += pack.Size * pack.Count
+				}
+				assert.Equal(t, row.TotalItems, packsSum, "quantity %d", row.Quantity)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Len(t, quantities, 86)
+			assert.Equal(t, 596, quantities[len(quantities)-1])
+		})
+	}
+}
+
+func TestSweep_StopsWhenContextIsDone(t *testing.T) {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{{Size: 23}, {Size: 31}}})
+	sweeper, err := uc.NewSweeper(context.Background(), 1, 5000000, 1000000)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows := 0
+	err = sweeper.Run(ctx, func(packusecase.SweepRow) error {
+		rows++
+		cancel() // The client went away after the first row
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, rows)
+}
+
+func TestSweep_InvalidRange(t *testing.T) {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{{Size: 250}}})
+
+	_, err := uc.NewSweeper(context.Background(), 10, 5, 1)
+	assert.ErrorIs(t, err, domain.ErrInvalidRange)
+	assert.EqualError(t, err, "invalid quantity range: range end must not be less than range start")
+
+	_, err = uc.NewSweeper(context.Background(), 1, 5, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidRange)
+	assert.EqualError(t, err, "invalid quantity range: step must be greater than 0")
+}
+
+func TestReverseLookup(t *testing.T) {
+	uc := packusecase.NewPackUseCase(&dynamicMockRepo{packs: []domain.Pack{
+		{Size: 250},
+		{Size: 500},
+		{Size: 1000},
+		{Size: 2000},
+		{Size: 5000},
+	}})
+
+	t.Run("Optimal combination returns its quantity range", func(t *testing.T) {
+		result, err := uc.ReverseLookup(context.Background(), map[int]int{5000: 1, 250: 1})
+		assert.NoError(t, err)
+		assert.True(t, result.Optimal)
+		assert.Equal(t, 5001, result.From)
+		assert.Equal(t, 5250, result.To)
+		assert.Empty(t, result.OptimalPacks)
+
+		for _, qty := range []int{result.From, result.To} {
+			calc, calcErr := uc.CalculatePacks(context.Background(), qty)
+			assert.NoError(t, calcErr)
+			assert.ElementsMatch(t, []packusecase.Pack{{Size: 5000, Count: 1}, {Size: 250, Count: 1}}, calc.Packs)
+		}
+	})
+
+	t.Run("Smallest pack covers every quantity from 1", func(t *testing.T) {
+		result, err := uc.ReverseLookup(context.Background(), map[int]int{250: 1})
+		assert.NoError(t, err)
+		assert.True(t, result.Optimal)
+		assert.Equal(t, 1, result.From)
+		assert.Equal(t, 250, result.To)
+	})
+
+	t.Run("Suboptimal combination reports the calculated one", func(t *testing.T) {
+		result, err := uc.ReverseLookup(context.Background(), map[int]int{5000: 1, 250: 2})
+		assert.NoError(t, err)
+		assert.False(t, result.Optimal)
+		assert.Equal(t, 5500, result.TotalItems)
+		assert.Equal(t, 3, result.TotalPacks)
+		assert.ElementsMatch(t, []packusecase.Pack{{Size: 5000, Count: 1}, {Size: 500, Count: 1}}, result.OptimalPacks)
+	})
+
+	t.Run("Unknown pack size", func(t *testing.T) {
+		_, err := uc.ReverseLookup(context.Background(), map[int]int{300: 1})
+		assert.ErrorIs(t, err, domain.ErrUnknownPackSize)
+	})
+
+	t.Run("Empty combination", func(t *testing.T) {
+		_, err := uc.ReverseLookup(context.Background(), map[int]int{250: 0})
+		assert.ErrorIs(t, err, domain.ErrInvalidCombination)
+	})
+}
+
+func TestCalculatePacks_Limits(t *testing.T) {
+	repo := &dynamicMockRepo{packs: []domain.Pack{{Size: 23}, {Size: 31}, {Size: 53}}}
+
+	t.Run("quantity above the maximum", func(t *testing.T) {
+		uc := packusecase.NewPackUseCase(repo, packusecase.WithLimits(packusecase.Limits{MaxQuantity: 1000}))
+
+		_, err := uc.CalculatePacks(context.Background(), 1001)
+		assert.ErrorIs(t, err, domain.ErrQuantityOutOfRange)
+
+		_, err = uc.NewSweeper(context.Background(), 990, 1010, 1)
+		assert.ErrorIs(t, err, domain.ErrQuantityOutOfRange)
+
+		_, err = uc.ReverseLookup(context.Background(), map[int]int{23: 44})
+		assert.EqualError(t, err, "invalid pack combination: must not exceed 1000 items")
+
+		_, err = uc.ReverseLookup(context.Background(), map[int]int{23: 43})
+		assert.NoError(t, err)
+	})
+
+	t.Run("state budget exceeded", func(t *testing.T) {
+		uc := packusecase.NewPackUseCase(repo, packusecase.WithLimits(packusecase.Limits{MaxStates: 10}))
+
+		_, err := uc.CalculatePacks(context.Background(), 500000)
+		assert.ErrorIs(t, err, domain.ErrSolverBudgetExceeded)
+
+		_, err = uc.ParetoFront(context.Background(), 500000, 0)
+		assert.ErrorIs(t, err, domain.ErrSolverBudgetExceeded)
+	})
+
+	t.Run("default state budget stops small coprime sizes", func(t *testing.T) {
+		uc := packusecase.NewPackUseCase(repo, packusecase.WithLimits(packusecase.Limits{MaxStates: configs.DefaultMaxStates}))
+
+		_, err := uc.CalculatePacks(context.Background(), 5000000)
+		assert.ErrorIs(t, err, domain.ErrSolverBudgetExceeded)
+	})
+}